@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+// runAPIKey implements the apikey subcommand.
+func runAPIKey(repo repository.APIKeyRepository, args []string) {
+	if repo == nil {
+		fatal("The memory storage driver cannot store API keys")
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "create" && len(args) == 2:
+		key, apiKey := domain.NewAPIKey(args[1])
+		if _, err := repo.CreateAPIKey(ctx, apiKey); err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				fatal("An API key with this name already exists", "name", args[1])
+			}
+			fatal("Failed to create API key", "error", err)
+		}
+		// The key cannot be recovered from its hash, so this is the only
+		// chance to see it.
+		fmt.Println(key)
+	case args[0] == "list" && len(args) == 1:
+		keys, err := repo.ListAPIKeys(ctx)
+		if err != nil {
+			fatal("Failed to list API keys", "error", err)
+		}
+		for _, key := range keys {
+			fmt.Printf("%-24s created %s\n", key.Name, key.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+		}
+	case args[0] == "revoke" && len(args) == 2:
+		if err := repo.DeleteAPIKey(ctx, args[1]); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				fatal("No API key has this name", "name", args[1])
+			}
+			fatal("Failed to revoke API key", "error", err)
+		}
+		fmt.Printf("Revoked API key %s\n", args[1])
+	default:
+		usage()
+	}
+}
@@ -1,69 +1,345 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionalphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 
+	"github.com/igoventura/go-grpc-library-service/internal/auth"
+	"github.com/igoventura/go-grpc-library-service/internal/certs"
+	"github.com/igoventura/go-grpc-library-service/internal/config"
+	"github.com/igoventura/go-grpc-library-service/internal/dbstats"
+	"github.com/igoventura/go-grpc-library-service/internal/healthcheck"
+	"github.com/igoventura/go-grpc-library-service/internal/migrate"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
 	"github.com/igoventura/go-grpc-library-service/internal/repository/cockroach"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/memory"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlite"
 	server "github.com/igoventura/go-grpc-library-service/internal/server"
+	"github.com/igoventura/go-grpc-library-service/internal/service"
+	"github.com/igoventura/go-grpc-library-service/migrations"
 	pb "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	err := godotenv.Load()
+	// Reported once the logger is configured.
+	envErr := godotenv.Load()
+
+	// Until log_level is known, errors go through the standard logger.
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Println("Warning: .env file not found, reading from environment")
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		log.Fatal("DATABASE_URL environment variable is not set")
+	if len(args) > 0 && args[0] == "config" {
+		runConfig(cfg, args[1:])
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	// Everything the server logs from here on is filtered by log_level.
+	level, _ := cfg.SlogLevel()
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	if envErr != nil {
+		slog.Warn(".env file not found, reading from environment")
+	}
+	if level <= slog.LevelDebug {
+		var effective strings.Builder
+		cfg.WriteYAML(&effective)
+		slog.Debug("Effective configuration:\n" + effective.String())
 	}
 
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	var (
+		db         *sql.DB
+		bookRepo   repository.BookRepository
+		apiKeyRepo repository.APIKeyRepository
+		migrator   *migrate.Migrator
+	)
+	switch cfg.Storage.Driver {
+	case "cockroach":
+		db = openDatabase(cfg)
+		bookRepo = cockroach.NewBookRepository(db)
+		apiKeyRepo = cockroach.NewAPIKeyRepository(db)
+		migrator = newMigrator(db, migrate.Cockroach, migrations.Cockroach())
+	case "sqlite":
+		db = openSQLite(cfg)
+		bookRepo = sqlite.NewBookRepository(db)
+		apiKeyRepo = sqlite.NewAPIKeyRepository(db)
+		migrator = newMigrator(db, migrate.SQLite, migrations.SQLite())
+	case "memory":
+		slog.Warn("Using in-memory storage, books will not survive a restart")
+		bookRepo = memory.NewBookRepository()
 	}
-	defer db.Close() // Ensure the connection is closed when main exits.
 
-	// It's good practice to ping the database to verify the connection.
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(migrator, args[1:])
+		if db != nil {
+			db.Close()
+		}
+		return
+	}
+	if len(args) > 0 && args[0] == "apikey" {
+		runAPIKey(apiKeyRepo, args[1:])
+		if db != nil {
+			db.Close()
+		}
+		return
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		usage()
+	}
+
+	if cfg.Features.MigrateOnStart && migrator != nil {
+		applyMigrations(migrator)
 	}
 
-	// Create a TCP listener on port 50051
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", cfg.ListenAddr)
 	if err != nil {
-		log.Fatalf("Failed to listen on port 50051: %v", err)
+		fatal("Failed to listen", "addr", cfg.ListenAddr, "error", err)
 	}
 
-	log.Println("Starting gRPC server on port 50051...")
+	slog.Info("Starting gRPC server", "addr", cfg.ListenAddr)
 
 	// Create a new gRPC server
-	grpcServer := grpc.NewServer()
+	// The actor interceptor runs after authentication so that writes are
+	// attributed to the authenticated principal.
+	unaryInterceptors := []grpc.UnaryServerInterceptor{server.ActorUnaryInterceptor}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if cfg.Auth.Enabled {
+		authenticator := newAuthenticator(cfg, apiKeyRepo)
+		unaryInterceptors = append([]grpc.UnaryServerInterceptor{authenticator.UnaryInterceptor}, unaryInterceptors...)
+		streamInterceptors = append(streamInterceptors, authenticator.StreamInterceptor)
+		slog.Info("Authentication enabled")
+	} else {
+		slog.Warn("Authentication is disabled, any client can change the catalog")
+	}
+	grpcOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+	reloadCtx, stopReloading := context.WithCancel(context.Background())
+	defer stopReloading()
+	if cfg.TLS.CertFile != "" {
+		reloader, err := certs.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			fatal("Failed to load TLS certificate", "error", err)
+		}
+		go reloader.Run(reloadCtx, cfg.TLS.ReloadInterval)
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+		if cfg.TLS.ClientCAFile != "" {
+			slog.Info("Mutual TLS enabled, client certificates are required")
+		} else {
+			slog.Info("TLS enabled")
+		}
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
 
-	bookRepo := cockroach.NewBookRepository(db)
+	serviceOpts := []service.Option{service.WithDeletedRetention(cfg.Books.DeletedRetention)}
+	if cfg.Books.PageTokenSecret != "" {
+		serviceOpts = append(serviceOpts, service.WithPageTokenSecret([]byte(cfg.Books.PageTokenSecret)))
+	} else {
+		slog.Warn("PAGE_TOKEN_SECRET is not set, page tokens will not survive a restart")
+	}
+	if cfg.Books.Watch {
+		serviceOpts = append(serviceOpts, service.WithWatchBooks())
+	}
 
 	// Create and register the library server
-	libraryServer := server.NewLibraryServer(bookRepo)
+	libraryServer := server.NewLibraryServer(bookRepo, serviceOpts...)
 	pb.RegisterLibraryServiceServer(grpcServer, libraryServer)
 
+	// Report connection pool statistics, when there is a pool, for sizing it.
+	var poolStats dbstats.Source
+	if db != nil {
+		poolStats = db
+	}
+	pb.RegisterAdminServiceServer(grpcServer, server.NewAdminServer(poolStats, cfg.Auth.AdminPrincipals()))
+
+	// Hard-delete books once their soft-delete retention has passed.
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	purgerDone := make(chan struct{})
+	go func() {
+		defer close(purgerDone)
+		libraryServer.RunPurger(purgerCtx, cfg.Books.PurgeInterval)
+	}()
+
+	// Report health so load balancers stop routing to a server whose database
+	// is unreachable or that is draining.
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	libraryService := pb.LibraryService_ServiceDesc.ServiceName
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	defer stopHealthChecks()
+	if db != nil {
+		checker := healthcheck.NewChecker(healthServer, db, libraryService)
+		go checker.Run(healthCtx, cfg.Health.CheckInterval)
+	} else {
+		healthServer.SetServingStatus(libraryService, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	var healthHTTP *http.Server
+	if addr := cfg.Health.HTTPAddr; addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", healthcheck.Handler(healthServer, libraryService))
+		if poolStats != nil {
+			mux.Handle("GET /metrics", dbstats.Handler(poolStats))
+		}
+		healthHTTP = &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			slog.Info("Serving health and metrics endpoints", "addr", addr)
+			if err := healthHTTP.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Failed to serve health endpoints", "error", err)
+			}
+		}()
+	}
+
 	// Enable gRPC reflection for debugging tools like grpcurl
-	reflection.Register(grpcServer)
+	if cfg.Features.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	slog.Info("Library gRPC service registered")
+	slog.Info("Server is ready to accept connections")
+
+	// Serve requests until SIGINT or SIGTERM, then drain them.
+	if err := serveUntilSignal(grpcServer, lis, healthServer, libraryServer.CloseWatches, cfg.Shutdown.DrainTimeout); err != nil {
+		fatal("Failed to serve gRPC server", "error", err)
+	}
+
+	stopHealthChecks()
+	stopReloading()
+	if healthHTTP != nil {
+		healthHTTP.Close()
+	}
+
+	// Let a purge in progress finish before its connection goes away.
+	stopPurger()
+	<-purgerDone
+	if db != nil {
+		slog.Info("Closing database connections")
+		if err := db.Close(); err != nil {
+			slog.Error("Failed to close database", "error", err)
+		}
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs msg with its attributes at error level and exits. Unlike
+// log.Fatal, it goes through the handler configured from log_level, which
+// the standard logger would only reach at info level.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// usage prints the subcommands and exits.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n%s", config.Usage)
+	os.Exit(2)
+}
+
+// runConfig implements the config subcommand.
+func runConfig(cfg *config.Config, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		usage()
+	}
 
-	log.Println("Library gRPC service registered")
-	log.Println("Server is ready to accept connections...")
+	if err := cfg.WriteYAML(os.Stdout); err != nil {
+		log.Fatalf("Failed to print configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+}
+
+// newAuthenticator builds the authenticator described by the auth section of
+// cfg. apiKeys is nil when the storage driver has no database.
+func newAuthenticator(cfg *config.Config, apiKeys repository.APIKeyRepository) *auth.Authenticator {
+	policy := auth.ClaimsPolicy{Issuer: cfg.Auth.JWTIssuer, Audience: cfg.Auth.JWTAudience}
 
-	// Start serving requests
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve gRPC server: %v", err)
+	var opts []auth.Option
+	switch {
+	case cfg.Auth.JWTSecret != "":
+		opts = append(opts, auth.WithJWT(auth.NewHMACVerifier([]byte(cfg.Auth.JWTSecret), policy)))
+	case cfg.Auth.JWKSFile != "":
+		verifier, err := auth.LoadJWKSVerifier(cfg.Auth.JWKSFile, policy)
+		if err != nil {
+			fatal("Failed to load JWKS", "error", err)
+		}
+		opts = append(opts, auth.WithJWT(verifier))
 	}
+	if cfg.Auth.APIKeys && apiKeys != nil {
+		opts = append(opts, auth.WithAPIKeys(apiKeys))
+	}
+	if cfg.Auth.ExemptHealth {
+		opts = append(opts, auth.WithExemptServices(healthpb.Health_ServiceDesc.ServiceName))
+	}
+	if cfg.Auth.ExemptReflection {
+		opts = append(opts, auth.WithExemptServices(
+			reflectionpb.ServerReflection_ServiceDesc.ServiceName,
+			reflectionalphapb.ServerReflection_ServiceDesc.ServiceName,
+		))
+	}
+	return auth.New(opts...)
+}
+
+func openDatabase(cfg *config.Config) *sql.DB {
+	dsn, err := cfg.Storage.DatabaseDSN()
+	if err != nil {
+		fatal("Invalid database URL", "error", err)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		fatal("Failed to connect to database", "error", err)
+	}
+	configurePool(db, cfg)
+
+	// It's good practice to ping the database to verify the connection.
+	if err := db.Ping(); err != nil {
+		fatal("Failed to ping database", "error", err)
+	}
+	return db
+}
+
+// openSQLite opens the database file named by storage.sqlite_path. Its schema
+// comes from the embedded SQLite migrations.
+func openSQLite(cfg *config.Config) *sql.DB {
+	db, err := sqlite.Open(cfg.Storage.SQLitePath)
+	if err != nil {
+		fatal("Failed to open SQLite database", "path", cfg.Storage.SQLitePath, "error", err)
+	}
+	configurePool(db, cfg)
+	return db
+}
+
+func configurePool(db *sql.DB, cfg *config.Config) {
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
 }
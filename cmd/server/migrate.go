@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strconv"
+
+	"github.com/igoventura/go-grpc-library-service/internal/migrate"
+)
+
+func newMigrator(db *sql.DB, dialect migrate.Dialect, fsys fs.FS) *migrate.Migrator {
+	migrator, err := migrate.New(db, dialect, fsys)
+	if err != nil {
+		fatal("Failed to load migrations", "error", err)
+	}
+	return migrator
+}
+
+// applyMigrations brings the schema up to date before the server starts.
+func applyMigrations(migrator *migrate.Migrator) {
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		fatal("Failed to apply migrations", "error", err)
+	}
+	if applied > 0 {
+		slog.Info("Applied migrations", "count", applied)
+	}
+}
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(migrator *migrate.Migrator, args []string) {
+	if migrator == nil {
+		fatal("The memory storage driver has no schema to migrate")
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fatal("Failed to apply migrations", "error", err)
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				fatal("migrate down expects a positive number of steps", "steps", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			fatal("Failed to revert migrations", "error", err)
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fatal("Failed to read migration status", "error", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%-8s %d_%s\n", state, status.Version, status.Name)
+		}
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			fatal("Failed to read schema version", "error", err)
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+	default:
+		usage()
+	}
+}
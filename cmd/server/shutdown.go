@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+)
+
+// serveUntilSignal serves grpcServer on lis until SIGINT or SIGTERM arrives,
+// then drains it. Health checks report NOT_SERVING first so load balancers
+// stop routing new calls, and closeStreams ends the streams that would
+// otherwise never finish, such as WatchBooks. In-flight calls get up to
+// drainTimeout to finish before the remaining ones are cancelled. A second
+// signal cancels them right away.
+func serveUntilSignal(grpcServer *grpc.Server, lis net.Listener, healthServer *health.Server, closeStreams func(), drainTimeout time.Duration) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig)
+	}
+
+	healthServer.Shutdown()
+	slog.Info("Health status set to NOT_SERVING")
+	closeStreams()
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	slog.Info("Draining in-flight calls", "timeout", drainTimeout)
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		slog.Info("All calls finished")
+		return nil
+	case <-timer.C:
+		slog.Warn("Drain timeout exceeded, cancelling remaining calls", "timeout", drainTimeout)
+	case sig := <-signals:
+		slog.Warn("Signal received again, cancelling remaining calls", "signal", sig)
+	}
+
+	grpcServer.Stop()
+	<-stopped
+	return nil
+}
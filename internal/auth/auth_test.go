@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "alice",
+		"iss": "https://issuer.example",
+		"aud": "library",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func withClaim(name string, value any) jwt.MapClaims {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestHMACVerifier(t *testing.T) {
+	verifier := NewHMACVerifier(testSecret, ClaimsPolicy{Issuer: "https://issuer.example", Audience: "library"})
+
+	subject, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, testSecret, "", validClaims()))
+	if err != nil || subject != "alice" {
+		t.Fatalf("Expected alice, got %q (%v)", subject, err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), "", validClaims())},
+		{"expired", sign(t, jwt.SigningMethodHS256, testSecret, "", withClaim("exp", time.Now().Add(-time.Minute).Unix()))},
+		{"no expiry", sign(t, jwt.SigningMethodHS256, testSecret, "", withClaim("exp", nil))},
+		{"wrong issuer", sign(t, jwt.SigningMethodHS256, testSecret, "", withClaim("iss", "https://other.example"))},
+		{"wrong audience", sign(t, jwt.SigningMethodHS256, testSecret, "", withClaim("aud", "billing"))},
+		{"no subject", sign(t, jwt.SigningMethodHS256, testSecret, "", withClaim("sub", nil))},
+		{"unsigned", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims())},
+		{"garbage", "not.a.token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token); err == nil {
+				t.Error("Expected the token to be rejected")
+			}
+		})
+	}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+			"n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256",
+			"x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": encode(edPublic)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := LoadJWKSVerifier(path, ClaimsPolicy{})
+	if err != nil {
+		t.Fatalf("LoadJWKSVerifier failed: %v", err)
+	}
+
+	valid := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    crypto.Signer
+		kid    string
+	}{
+		{"RSA", jwt.SigningMethodRS256, rsaKey, "rsa"},
+		{"ECDSA", jwt.SigningMethodES256, ecKey, "ec"},
+		{"Ed25519", jwt.SigningMethodEdDSA, edKey, "ed"},
+	}
+	for _, tt := range valid {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := verifier.Verify(sign(t, tt.method, tt.key, tt.kid, validClaims()))
+			if err != nil || subject != "alice" {
+				t.Errorf("Expected alice, got %q (%v)", subject, err)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		token string
+	}{
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, rsaKey, "other", validClaims())},
+		{"missing kid", sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims())},
+		{"kid of another key", sign(t, jwt.SigningMethodES256, ecKey, "rsa", validClaims())},
+		{"algorithm not of the key", sign(t, jwt.SigningMethodPS256, rsaKey, "rsa", validClaims())},
+		{"HMAC with the public key", sign(t, jwt.SigningMethodHS256, data, "rsa", validClaims())},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token); err == nil {
+				t.Error("Expected the token to be rejected")
+			}
+		})
+	}
+}
+
+func TestLoadJWKSVerifier_Errors(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":         `{`,
+		"no keys":          `{"keys": []}`,
+		"unsupported type": `{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`,
+		"bad curve point":  `{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+		"duplicate kid":    `{"keys": [{"kty": "OKP", "kid": "a", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}, {"kty": "OKP", "kid": "a", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+	}
+	for name, jwks := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadJWKSVerifier(path, ClaimsPolicy{}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+type fakeKeyStore struct {
+	keys map[string]string
+	err  error
+}
+
+func (f *fakeKeyStore) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for key, name := range f.keys {
+		if domain.HashAPIKey(key) == hash {
+			return &domain.APIKey{Name: name, Hash: hash}, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func incoming(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	store := &fakeKeyStore{keys: map[string]string{"lib_key": "ci"}}
+	a := New(WithJWT(NewHMACVerifier(testSecret, ClaimsPolicy{})), WithAPIKeys(store))
+	token := sign(t, jwt.SigningMethodHS256, testSecret, "", validClaims())
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want Principal
+		code codes.Code
+	}{
+		{"bearer token", incoming("authorization", "Bearer "+token), Principal{Subject: "alice", Method: MethodJWT}, codes.OK},
+		{"lowercase scheme", incoming("authorization", "bearer "+token), Principal{Subject: "alice", Method: MethodJWT}, codes.OK},
+		{"API key", incoming("x-api-key", "lib_key"), Principal{Subject: "ci", Method: MethodAPIKey}, codes.OK},
+		{"invalid token", incoming("authorization", "Bearer "+token+"x"), Principal{}, codes.Unauthenticated},
+		{"basic auth", incoming("authorization", "Basic YWxpY2U6cHc="), Principal{}, codes.Unauthenticated},
+		{"unknown API key", incoming("x-api-key", "lib_other"), Principal{}, codes.Unauthenticated},
+		{"no metadata", context.Background(), Principal{}, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(tt.ctx)
+			if status.Code(err) != tt.code {
+				t.Fatalf("Expected %v, got %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	store.err = errors.New("connection refused")
+	if _, err := a.Authenticate(incoming("x-api-key", "lib_key")); status.Code(err) != codes.Internal {
+		t.Errorf("Expected Internal when the key store fails, got %v", err)
+	}
+
+	none := New()
+	for _, ctx := range []context.Context{incoming("authorization", "Bearer "+token), incoming("x-api-key", "lib_key")} {
+		if _, err := none.Authenticate(ctx); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected credentials to be refused without verifiers, got %v", err)
+		}
+	}
+}
+
+func TestAuthenticator_Interceptors(t *testing.T) {
+	a := New(WithAPIKeys(&fakeKeyStore{keys: map[string]string{"lib_key": "ci"}}), WithExemptServices("grpc.health.v1.Health"))
+
+	var seen Principal
+	var authenticated bool
+	unary := func(ctx context.Context, req any) (any, error) {
+		seen, authenticated = PrincipalFromContext(ctx)
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		seen, authenticated = Principal{}, false
+		_, err := a.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, unary)
+		return err
+	}
+
+	if err := call(incoming("x-api-key", "lib_key"), "/library.v1.LibraryService/DeleteBook"); err != nil || seen.String() != "api-key:ci" {
+		t.Errorf("Expected api-key:ci in the context, got %v (%v)", seen, err)
+	}
+	if err := call(context.Background(), "/library.v1.LibraryService/DeleteBook"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+	if err := call(context.Background(), "/grpc.health.v1.Health/Check"); err != nil || authenticated {
+		t.Errorf("Expected exempt call to pass unauthenticated, got %v (%v)", seen, err)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream) error {
+		seen, authenticated = PrincipalFromContext(ss.Context())
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/library.v1.LibraryService/WatchBooks", IsServerStream: true}
+	if err := a.StreamInterceptor(nil, &fakeStream{ctx: incoming("x-api-key", "lib_key")}, info, stream); err != nil || seen.Subject != "ci" {
+		t.Errorf("Expected ci in the stream context, got %v (%v)", seen, err)
+	}
+	if err := a.StreamInterceptor(nil, &fakeStream{ctx: context.Background()}, info, stream); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for a stream, got %v", err)
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestPrincipal_String(t *testing.T) {
+	key := Principal{Subject: "ci", Method: MethodAPIKey}
+	if got := key.String(); got != "api-key:ci" {
+		t.Errorf("Expected api-key:ci, got %q", got)
+	}
+	// A token cannot claim to be an API key.
+	token := Principal{Subject: "api-key:ci", Method: MethodJWT}
+	if got := token.String(); got != "jwt:api-key:ci" {
+		t.Errorf("Expected jwt:api-key:ci, got %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// authorizationHeader carries a bearer JWT: "Bearer <token>".
+	authorizationHeader = "authorization"
+	// apiKeyHeader carries an API key.
+	apiKeyHeader = "x-api-key"
+)
+
+// APIKeyStore looks API keys up by hash. It is implemented by
+// repository.APIKeyRepository.
+type APIKeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+}
+
+// Authenticator rejects calls without valid credentials and records the
+// principal of the others in their context.
+type Authenticator struct {
+	jwt     *JWTVerifier
+	apiKeys APIKeyStore
+	exempt  map[string]bool
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithJWT accepts bearer tokens checked by verifier.
+func WithJWT(verifier *JWTVerifier) Option {
+	return func(a *Authenticator) {
+		a.jwt = verifier
+	}
+}
+
+// WithAPIKeys accepts the API keys found in store.
+func WithAPIKeys(store APIKeyStore) Option {
+	return func(a *Authenticator) {
+		a.apiKeys = store
+	}
+}
+
+// WithExemptServices lets calls to the named services, such as
+// grpc.health.v1.Health, through without credentials.
+func WithExemptServices(services ...string) Option {
+	return func(a *Authenticator) {
+		for _, service := range services {
+			a.exempt[service] = true
+		}
+	}
+}
+
+// New returns an Authenticator. Without WithJWT or WithAPIKeys it rejects
+// every call to a service that is not exempt.
+func New(opts ...Option) *Authenticator {
+	a := &Authenticator{exempt: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// UnaryInterceptor authenticates unary calls.
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if a.isExempt(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	principal, err := a.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(WithPrincipal(ctx, principal), req)
+}
+
+// StreamInterceptor authenticates streaming calls.
+func (a *Authenticator) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if a.isExempt(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	principal, err := a.Authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: WithPrincipal(ss.Context(), principal)})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// isExempt reports whether fullMethod, /package.Service/Method, belongs to
+// an exempt service.
+func (a *Authenticator) isExempt(fullMethod string) bool {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return a.exempt[service]
+}
+
+// Authenticate returns the principal whose credentials are in the incoming
+// metadata of ctx. Missing or invalid credentials are reported with
+// codes.Unauthenticated.
+func (a *Authenticator) Authenticate(ctx context.Context) (Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get(authorizationHeader); len(values) > 0 {
+		scheme, token, _ := strings.Cut(values[0], " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, status.Error(codes.Unauthenticated, "authorization header must be of the form \"Bearer <token>\"")
+		}
+		if a.jwt == nil {
+			return Principal{}, status.Error(codes.Unauthenticated, "bearer tokens are not accepted")
+		}
+		subject, err := a.jwt.Verify(token)
+		if err != nil {
+			return Principal{}, status.Errorf(codes.Unauthenticated, "invalid bearer token: %v", err)
+		}
+		return Principal{Subject: subject, Method: MethodJWT}, nil
+	}
+
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		if a.apiKeys == nil {
+			return Principal{}, status.Error(codes.Unauthenticated, "API keys are not accepted")
+		}
+		key, err := a.apiKeys.GetAPIKeyByHash(ctx, domain.HashAPIKey(values[0]))
+		if errors.Is(err, repository.ErrNotFound) {
+			return Principal{}, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if err != nil {
+			slog.Error("Failed to look up API key", "error", err)
+			return Principal{}, status.Error(codes.Internal, "failed to check API key")
+		}
+		return Principal{Subject: key.Name, Method: MethodAPIKey}, nil
+	}
+
+	return Principal{}, status.Error(codes.Unauthenticated, "missing credentials: send a bearer token in the authorization header or an API key in x-api-key")
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsPolicy lists the claims tokens must carry besides a valid signature,
+// an expiry in the future and a subject. Empty fields are not checked.
+type ClaimsPolicy struct {
+	Issuer   string
+	Audience string
+}
+
+// JWTVerifier validates bearer tokens.
+type JWTVerifier struct {
+	parser  *jwt.Parser
+	keyfunc jwt.Keyfunc
+}
+
+// NewHMACVerifier accepts tokens signed with secret using HS256, HS384 or
+// HS512.
+func NewHMACVerifier(secret []byte, policy ClaimsPolicy) *JWTVerifier {
+	return &JWTVerifier{
+		parser: newParser(policy, []string{"HS256", "HS384", "HS512"}),
+		keyfunc: func(*jwt.Token) (any, error) {
+			return secret, nil
+		},
+	}
+}
+
+// LoadJWKSVerifier accepts tokens signed by one of the public keys of the
+// JSON Web Key Set in the file at path. RSA, ECDSA and Ed25519 keys are
+// supported; tokens must name their key with a kid header unless the set
+// holds a single key.
+func LoadJWKSVerifier(path string, policy ClaimsPolicy) (*JWTVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", path, err)
+	}
+
+	keyfunc := func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok && kid == "" && len(keys) == 1 {
+			for _, only := range keys {
+				key, ok = only, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if key.alg != "" && key.alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q is for %s, not %s", kid, key.alg, token.Method.Alg())
+		}
+		return key.public, nil
+	}
+	algs := []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	return &JWTVerifier{parser: newParser(policy, algs), keyfunc: keyfunc}, nil
+}
+
+func newParser(policy ClaimsPolicy, algs []string) *jwt.Parser {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(algs), jwt.WithExpirationRequired()}
+	if policy.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(policy.Issuer))
+	}
+	if policy.Audience != "" {
+		opts = append(opts, jwt.WithAudience(policy.Audience))
+	}
+	return jwt.NewParser(opts...)
+}
+
+// Verify checks token and returns its subject.
+func (v *JWTVerifier) Verify(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.keyfunc); err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", errors.New("token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+// jwk is a JSON Web Key as defined by RFC 7517 and RFC 8037, restricted to
+// the public members of the supported key types.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKey struct {
+	alg    string
+	public crypto.PublicKey
+}
+
+// parseJWKS returns the signature keys of a JWKS by kid. Encryption keys are
+// skipped.
+func parseJWKS(data []byte) (map[string]verificationKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]verificationKey)
+	for i, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		public, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%q): %w", i, k.Kid, err)
+		}
+		if _, dup := keys[k.Kid]; dup {
+			return nil, fmt.Errorf("key %d: duplicate kid %q", i, k.Kid)
+		}
+		keys[k.Kid] = verificationKey{alg: k.Alg, public: public}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signature keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("e is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("x is not an Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("missing")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,43 @@
+// Package auth authenticates gRPC calls with bearer JWTs or API keys and
+// makes the caller available to handlers as a Principal.
+package auth
+
+import "context"
+
+// Method is how a principal proved its identity.
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the sub claim of a JWT or the name of an API key.
+	Subject string
+	Method  Method
+}
+
+// String returns the name the principal's writes are attributed to,
+// "jwt:<sub>" or "api-key:<name>". Both are prefixed so that a token subject
+// cannot pass for an API key, or the other way round.
+func (p Principal) String() string {
+	if p.Method == MethodAPIKey {
+		return "api-key:" + p.Subject
+	}
+	return "jwt:" + p.Subject
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal of an authenticated request.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,138 @@
+// Package certs serves TLS certificates that are rotated on disk, such as
+// those renewed by cert-manager, without restarting the server.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader holds the server certificate and, for mutual TLS, the CA bundle
+// client certificates are verified against. Both are read again from disk by
+// Reload; handshakes always use the latest that loaded successfully.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	// loaded holds the contents of each file as last loaded, to tell whether
+	// it changed.
+	loaded map[string][]byte
+}
+
+// NewReloader loads the key pair and, when clientCAFile is not empty, the CA
+// bundle used to require and verify client certificates.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig returns a TLS configuration taking the certificate and client
+// CAs from r on every handshake.
+func (r *Reloader) ServerConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// gRPC clients insist on negotiating HTTP/2, and the configuration
+		// returned by GetConfigForClient replaces the one credentials.NewTLS
+		// would have added it to.
+		NextProtos: []string{"h2"},
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		handshake := config.Clone()
+		handshake.GetConfigForClient = nil
+		handshake.Certificates = []tls.Certificate{*r.cert}
+		if r.clientCAs != nil {
+			handshake.ClientAuth = tls.RequireAndVerifyClientCert
+			handshake.ClientCAs = r.clientCAs
+		}
+		return handshake, nil
+	}
+	return config
+}
+
+// Reload reads the files again and reports whether any of them changed. When
+// they no longer load, for instance because the certificate was replaced
+// but not yet its key, the previous ones stay in use and the error is
+// returned.
+func (r *Reloader) Reload() (bool, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+
+	contents := make(map[string][]byte, len(files))
+	changed := false
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		contents[path] = data
+
+		r.mu.RLock()
+		previous, ok := r.loaded[path]
+		r.mu.RUnlock()
+		changed = changed || !ok || !bytes.Equal(previous, data)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(contents[r.certFile], contents[r.keyFile])
+	if err != nil {
+		return false, fmt.Errorf("load key pair %s and %s: %w", r.certFile, r.keyFile, err)
+	}
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(contents[r.clientCAFile]) {
+			return false, fmt.Errorf("load client CAs: no PEM certificate found in %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.loaded = contents
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Run reloads the files every interval until ctx is done. Outcomes are
+// logged, and failures retried at the next interval.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := r.Reload()
+		switch {
+		case err != nil:
+			slog.Error("Failed to reload TLS certificates, still serving the previous ones", "error", err)
+		case changed:
+			slog.Info("Reloaded TLS certificate", "file", r.certFile)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for localhost with the given serial
+// number.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// handshake connects a client using clientConfig to a server using
+// serverConfig and returns the serial number of the certificate the server
+// presented.
+func handshake(serverConfig, clientConfig *tls.Config) (int64, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer lis.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- tls.Server(conn, serverConfig).Handshake()
+	}()
+
+	client, err := tls.Dial("tcp", lis.Addr().String(), clientConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	// With TLS 1.3 the client finishes before the server has checked the
+	// client certificate.
+	if err := <-serverErr; err != nil {
+		return 0, err
+	}
+	return client.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestReloader_Rotation(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	cert, key := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+
+	reloader, err := NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("NewReloader failed: %v", err)
+	}
+	serverConfig := reloader.ServerConfig()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+
+	if serial, err := handshake(serverConfig, clientConfig); err != nil || serial != 100 {
+		t.Fatalf("Expected certificate 100, got %d (%v)", serial, err)
+	}
+
+	if changed, err := reloader.Reload(); changed || err != nil {
+		t.Errorf("Expected no change, got %v (%v)", changed, err)
+	}
+
+	cert, key = ca.issue(t, 200, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	if changed, err := reloader.Reload(); !changed || err != nil {
+		t.Fatalf("Expected the rotated certificate to load, got %v (%v)", changed, err)
+	}
+	if serial, err := handshake(serverConfig, clientConfig); err != nil || serial != 200 {
+		t.Errorf("Expected certificate 200 after rotation, got %d (%v)", serial, err)
+	}
+
+	// A certificate replaced before its key does not load; the previous pair
+	// is kept until the key follows.
+	cert, _ = ca.issue(t, 300, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	if _, err := reloader.Reload(); err == nil {
+		t.Error("Expected mismatched key pair to fail")
+	}
+	if serial, err := handshake(serverConfig, clientConfig); err != nil || serial != 200 {
+		t.Errorf("Expected certificate 200 to stay in use, got %d (%v)", serial, err)
+	}
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	cert, key := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	writeFile(t, caFile, ca.pem)
+
+	reloader, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("NewReloader failed: %v", err)
+	}
+	serverConfig := reloader.ServerConfig()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	if _, err := handshake(serverConfig, &tls.Config{RootCAs: roots, ServerName: "localhost"}); err == nil {
+		t.Error("Expected a client without a certificate to be rejected")
+	}
+
+	clientCert, clientKey := ca.issue(t, 500, x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{pair}}
+	if _, err := handshake(serverConfig, clientConfig); err != nil {
+		t.Errorf("Expected a client certificate signed by the CA to be accepted, got %v", err)
+	}
+
+	otherCert, otherKey := newTestCA(t).issue(t, 600, x509.ExtKeyUsageClientAuth)
+	pair, err = tls.X509KeyPair(otherCert, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConfig.Certificates = []tls.Certificate{pair}
+	if _, err := handshake(serverConfig, clientConfig); err == nil {
+		t.Error("Expected a client certificate from another CA to be rejected")
+	}
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), ""); err == nil {
+		t.Error("Expected missing files to fail")
+	}
+
+	ca := newTestCA(t)
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	cert, key := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	writeFile(t, caFile, []byte("not a certificate"))
+	if _, err := NewReloader(certFile, keyFile, caFile); err == nil {
+		t.Error("Expected a CA file without certificates to fail")
+	}
+}
@@ -0,0 +1,381 @@
+// Package config loads the server configuration. Every setting has a
+// default and can be overridden, from lowest to highest precedence, by a
+// YAML config file, an environment variable and a command-line flag.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the effective server configuration.
+type Config struct {
+	ListenAddr string         `yaml:"listen_addr"`
+	LogLevel   string         `yaml:"log_level"`
+	Storage    StorageConfig  `yaml:"storage"`
+	Database   DatabaseConfig `yaml:"database"`
+	TLS        TLSConfig      `yaml:"tls"`
+	Auth       AuthConfig     `yaml:"auth"`
+	Shutdown   ShutdownConfig `yaml:"shutdown"`
+	Health     HealthConfig   `yaml:"health"`
+	Books      BooksConfig    `yaml:"books"`
+	Features   FeaturesConfig `yaml:"features"`
+}
+
+type StorageConfig struct {
+	// Driver is cockroach, sqlite or memory.
+	Driver      string `yaml:"driver"`
+	DatabaseURL string `yaml:"database_url"`
+	// DatabaseSSLMode and DatabaseSSLRootCert override the sslmode and
+	// sslrootcert parameters of DatabaseURL when set.
+	DatabaseSSLMode     string `yaml:"database_sslmode"`
+	DatabaseSSLRootCert string `yaml:"database_sslrootcert"`
+	SQLitePath          string `yaml:"sqlite_path"`
+}
+
+// DatabaseConfig sizes the connection pool. Zero MaxOpenConns means no
+// limit, and a zero ConnMaxLifetime or ConnMaxIdleTime keeps connections
+// open for good.
+type DatabaseConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// TLSConfig enables TLS on the gRPC listener when both files are set.
+// ClientCAFile additionally requires clients to present a certificate signed
+// by one of its CAs. The files are read again every ReloadInterval, so
+// rotated certificates are picked up without a restart.
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// AuthConfig requires callers to authenticate when Enabled, which is the
+// default. Bearer JWTs are accepted when JWTSecret or JWKSFile is set, and
+// API keys when APIKeys is set and the storage driver has a database to keep
+// them in. Validate fails when Enabled leaves no way to authenticate, so
+// serving without authentication takes an explicit Enabled=false.
+type AuthConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	APIKeys     bool   `yaml:"api_keys"`
+	JWTSecret   string `yaml:"jwt_secret"`
+	JWKSFile    string `yaml:"jwks_file"`
+	JWTIssuer   string `yaml:"jwt_issuer"`
+	JWTAudience string `yaml:"jwt_audience"`
+	// ExemptHealth and ExemptReflection let the health and reflection
+	// services be called without credentials.
+	ExemptHealth     bool `yaml:"exempt_health"`
+	ExemptReflection bool `yaml:"exempt_reflection"`
+	// Admins is a comma-separated list of the principals, such as
+	// api-key:ops or jwt:alice, allowed to call AdminService. Nobody is by
+	// default.
+	Admins string `yaml:"admins"`
+}
+
+// AdminPrincipals splits Admins into principals.
+func (c AuthConfig) AdminPrincipals() []string {
+	var admins []string
+	for _, admin := range strings.Split(c.Admins, ",") {
+		if admin = strings.TrimSpace(admin); admin != "" {
+			admins = append(admins, admin)
+		}
+	}
+	return admins
+}
+
+type ShutdownConfig struct {
+	// DrainTimeout has to stay below the time the orchestrator waits before
+	// killing the process, 30s by default on Kubernetes, so that the
+	// database is still closed cleanly.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+type HealthConfig struct {
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// HTTPAddr serves /healthz, /readyz and /metrics when set.
+	HTTPAddr string `yaml:"http_addr"`
+}
+
+type BooksConfig struct {
+	PageTokenSecret  string        `yaml:"page_token_secret"`
+	DeletedRetention time.Duration `yaml:"deleted_retention"`
+	PurgeInterval    time.Duration `yaml:"purge_interval"`
+	// Watch serves WatchBooks, whose change feed only sees the writes made
+	// through the same replica.
+	Watch bool `yaml:"watch"`
+}
+
+type FeaturesConfig struct {
+	MigrateOnStart bool `yaml:"migrate_on_start"`
+	Reflection     bool `yaml:"reflection"`
+}
+
+// minJWTSecretLength is the shortest HMAC secret accepted, the size of an
+// HS256 signature.
+const minJWTSecretLength = 32
+
+// Default returns the configuration used when nothing is overridden.
+func Default() *Config {
+	return &Config{
+		ListenAddr: ":50051",
+		LogLevel:   "info",
+		Storage: StorageConfig{
+			Driver:     "cockroach",
+			SQLitePath: "library.db",
+		},
+		Database: DatabaseConfig{
+			MaxIdleConns: 2,
+		},
+		TLS: TLSConfig{
+			ReloadInterval: time.Minute,
+		},
+		Auth: AuthConfig{
+			Enabled:          true,
+			APIKeys:          true,
+			ExemptHealth:     true,
+			ExemptReflection: true,
+		},
+		Shutdown: ShutdownConfig{
+			DrainTimeout: 20 * time.Second,
+		},
+		Health: HealthConfig{
+			CheckInterval: 10 * time.Second,
+		},
+		Books: BooksConfig{
+			DeletedRetention: 30 * 24 * time.Hour,
+			PurgeInterval:    time.Hour,
+		},
+		Features: FeaturesConfig{
+			Reflection: true,
+		},
+	}
+}
+
+// Load builds the configuration from args, which exclude the program name,
+// and from the environment as read by getenv. The config file is named by
+// the --config flag or the CONFIG_FILE variable. It returns the arguments
+// left after the flags, such as a subcommand. A -h or --help flag returns
+// flag.ErrHelp after printing usage to output.
+//
+// The result is not validated, so that an invalid configuration can still be
+// printed; call Validate before using it.
+func Load(args []string, getenv func(string) string, output io.Writer) (*Config, []string, error) {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configFile := flags.String("config", getenv("CONFIG_FILE"), "YAML config file (env CONFIG_FILE)")
+
+	// Flags are only recorded while parsing and applied last, so they win
+	// over the config file they may have named.
+	set := make(map[string]string)
+	for _, s := range settings(Default()) {
+		usage := fmt.Sprintf("%s (env %s, config %s)", s.usage, s.env, s.key)
+		flags.Var(&recorder{name: s.flag, set: set, def: s.get(), isBool: s.isBool()}, s.flag, usage)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(output, "Usage:\n%s\nFlags:\n", Usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := cfg.readFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, s := range settings(cfg) {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings(cfg) {
+		if value, ok := set[s.flag]; ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("--%s: %w", s.flag, err)
+			}
+		}
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+// Usage lists the subcommands, for the server's help output.
+const Usage = `  server [flags]                      serve the library API
+  server [flags] migrate up           apply every pending migration
+  server [flags] migrate down [N]     revert the last N migrations (default 1)
+  server [flags] migrate status       list migrations and whether they are applied
+  server [flags] migrate version      print the current schema version
+  server [flags] config print         print the effective configuration, secrets redacted
+  server [flags] apikey create NAME   create an API key and print it, once
+  server [flags] apikey list          list API keys
+  server [flags] apikey revoke NAME   delete an API key
+`
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ListenAddr != "", "listen_addr must be set")
+	_, err := c.SlogLevel()
+	check(err == nil, "log_level must be debug, info, warn or error, got %q", c.LogLevel)
+
+	switch c.Storage.Driver {
+	case "cockroach":
+		check(c.Storage.DatabaseURL != "", "storage.database_url (DATABASE_URL) is required by the cockroach driver")
+		switch c.Storage.DatabaseSSLMode {
+		case "", "disable", "require", "verify-ca", "verify-full":
+		default:
+			check(false, "storage.database_sslmode must be disable, require, verify-ca or verify-full, got %q", c.Storage.DatabaseSSLMode)
+		}
+		if path := c.Storage.DatabaseSSLRootCert; path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, "storage.database_sslrootcert: %v", err)
+		}
+	case "sqlite":
+		check(c.Storage.SQLitePath != "", "storage.sqlite_path is required by the sqlite driver")
+	case "memory":
+	default:
+		check(false, "storage.driver must be cockroach, sqlite or memory, got %q", c.Storage.Driver)
+	}
+
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file and tls.key_file must be set together")
+	check(c.TLS.ClientCAFile == "" || c.TLS.CertFile != "", "tls.client_ca_file requires tls.cert_file and tls.key_file")
+	for _, path := range []string{c.TLS.CertFile, c.TLS.KeyFile, c.TLS.ClientCAFile} {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, "tls: %v", err)
+		}
+	}
+	check(c.TLS.ReloadInterval > 0, "tls.reload_interval must be positive")
+
+	check(c.Auth.JWTSecret == "" || c.Auth.JWKSFile == "", "auth.jwt_secret and auth.jwks_file are mutually exclusive")
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= minJWTSecretLength, "auth.jwt_secret must be at least %d bytes long", minJWTSecretLength)
+	if path := c.Auth.JWKSFile; path != "" {
+		_, err := os.Stat(path)
+		check(err == nil, "auth.jwks_file: %v", err)
+	}
+	for _, admin := range c.Auth.AdminPrincipals() {
+		check(strings.HasPrefix(admin, "jwt:") || strings.HasPrefix(admin, "api-key:"), "auth.admins: %q is neither jwt:<subject> nor api-key:<name>", admin)
+	}
+	if c.Auth.Enabled {
+		jwt := c.Auth.JWTSecret != "" || c.Auth.JWKSFile != ""
+		apiKeys := c.Auth.APIKeys && c.Storage.Driver != "memory"
+		check(jwt || apiKeys, "auth.enabled requires auth.jwt_secret, auth.jwks_file, or auth.api_keys with a database storage driver; set auth.enabled=false (AUTH_ENABLED=false) to serve without authentication")
+	}
+
+	check(c.Shutdown.DrainTimeout > 0, "shutdown.drain_timeout must be positive")
+	check(c.Health.CheckInterval > 0, "health.check_interval must be positive")
+	check(c.Books.DeletedRetention > 0, "books.deleted_retention must be positive")
+	check(c.Books.PurgeInterval > 0, "books.purge_interval must be positive")
+
+	return errors.Join(errs...)
+}
+
+// DatabaseDSN returns DatabaseURL with the SSL settings applied. Both the
+// URL and the key=value forms of connection strings are understood.
+func (c StorageConfig) DatabaseDSN() (string, error) {
+	params := [][2]string{{"sslmode", c.DatabaseSSLMode}, {"sslrootcert", c.DatabaseSSLRootCert}}
+
+	if strings.HasPrefix(c.DatabaseURL, "postgres://") || strings.HasPrefix(c.DatabaseURL, "postgresql://") {
+		u, err := url.Parse(c.DatabaseURL)
+		if err != nil {
+			return "", fmt.Errorf("parse database URL: %w", err)
+		}
+		query := u.Query()
+		for _, p := range params {
+			if p[1] != "" {
+				query.Set(p[0], p[1])
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	// Later keys win in key=value connection strings.
+	dsn := c.DatabaseURL
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, p := range params {
+		if p[1] != "" {
+			dsn += fmt.Sprintf(" %s='%s'", p[0], quote.Replace(p[1]))
+		}
+	}
+	return dsn, nil
+}
+
+// SlogLevel parses LogLevel.
+func (c *Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToLower(c.LogLevel)))
+	return level, err
+}
+
+// recorder is a flag.Value remembering the raw value of a flag. Until the
+// flag is set it shows def, the default value.
+type recorder struct {
+	name   string
+	set    map[string]string
+	def    string
+	isBool bool
+}
+
+func (r *recorder) String() string {
+	if r == nil || r.set == nil {
+		return ""
+	}
+	if value, ok := r.set[r.name]; ok {
+		return value
+	}
+	return r.def
+}
+
+func (r *recorder) Set(value string) error {
+	r.set[r.name] = value
+	return nil
+}
+
+func (r *recorder) IsBoolFlag() bool {
+	return r.isBool
+}
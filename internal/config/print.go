@@ -0,0 +1,42 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WriteYAML writes c in the config file format, with secrets redacted.
+func (c *Config) WriteYAML(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings(c) {
+		parent := root
+		path := strings.Split(s.key, ".")
+		for _, name := range path[:len(path)-1] {
+			parent = child(parent, name)
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: s.tag(), Value: s.redacted()}
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[len(path)-1]}, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// child returns the mapping stored under name in parent, adding it if needed.
+func child(parent *yaml.Node, name string) *yaml.Node {
+	for i := 0; i < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			return parent.Content[i+1]
+		}
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, node)
+	return node
+}
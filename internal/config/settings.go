@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting binds one configuration value to its config file key, environment
+// variable and flag.
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	// secret settings are redacted when the configuration is printed.
+	secret bool
+	ptr    any
+}
+
+// settings lists every setting of c, in the order they are printed.
+func settings(c *Config) []setting {
+	return []setting{
+		{key: "listen_addr", env: "LISTEN_ADDR", flag: "listen-addr", usage: "gRPC listen address", ptr: &c.ListenAddr},
+		{key: "log_level", env: "LOG_LEVEL", flag: "log-level", usage: "debug, info, warn or error", ptr: &c.LogLevel},
+
+		{key: "storage.driver", env: "STORAGE_DRIVER", flag: "storage-driver", usage: "cockroach, sqlite or memory", ptr: &c.Storage.Driver},
+		{key: "storage.database_url", env: "DATABASE_URL", flag: "database-url", usage: "CockroachDB connection string", secret: true, ptr: &c.Storage.DatabaseURL},
+		{key: "storage.database_sslmode", env: "DATABASE_SSLMODE", flag: "database-sslmode", usage: "sslmode of the CockroachDB connection: disable, require, verify-ca or verify-full", ptr: &c.Storage.DatabaseSSLMode},
+		{key: "storage.database_sslrootcert", env: "DATABASE_SSLROOTCERT", flag: "database-sslrootcert", usage: "CA certificate verifying the CockroachDB server", ptr: &c.Storage.DatabaseSSLRootCert},
+		{key: "storage.sqlite_path", env: "SQLITE_PATH", flag: "sqlite-path", usage: "SQLite database file", ptr: &c.Storage.SQLitePath},
+
+		{key: "database.max_open_conns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum open database connections, 0 for no limit", ptr: &c.Database.MaxOpenConns},
+		{key: "database.max_idle_conns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum idle database connections", ptr: &c.Database.MaxIdleConns},
+		{key: "database.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "close database connections older than this, 0 to keep them", ptr: &c.Database.ConnMaxLifetime},
+		{key: "database.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "close database connections idle for longer than this, 0 to keep them", ptr: &c.Database.ConnMaxIdleTime},
+
+		{key: "tls.cert_file", env: "TLS_CERT_FILE", flag: "tls-cert-file", usage: "PEM certificate served by the gRPC listener", ptr: &c.TLS.CertFile},
+		{key: "tls.key_file", env: "TLS_KEY_FILE", flag: "tls-key-file", usage: "PEM private key of the certificate", ptr: &c.TLS.KeyFile},
+		{key: "tls.client_ca_file", env: "TLS_CLIENT_CA_FILE", flag: "tls-client-ca-file", usage: "PEM CA bundle; when set, clients must present a certificate it signed", ptr: &c.TLS.ClientCAFile},
+		{key: "tls.reload_interval", env: "TLS_RELOAD_INTERVAL", flag: "tls-reload-interval", usage: "how often the TLS files are checked for rotated certificates", ptr: &c.TLS.ReloadInterval},
+
+		{key: "auth.enabled", env: "AUTH_ENABLED", flag: "auth", usage: "require callers to authenticate with a bearer JWT or an API key", ptr: &c.Auth.Enabled},
+		{key: "auth.api_keys", env: "AUTH_API_KEYS", flag: "auth-api-keys", usage: "accept the API keys stored in the database", ptr: &c.Auth.APIKeys},
+		{key: "auth.jwt_secret", env: "AUTH_JWT_SECRET", flag: "auth-jwt-secret", usage: "HMAC secret verifying bearer JWTs", secret: true, ptr: &c.Auth.JWTSecret},
+		{key: "auth.jwks_file", env: "AUTH_JWKS_FILE", flag: "auth-jwks-file", usage: "JSON Web Key Set file verifying bearer JWTs", ptr: &c.Auth.JWKSFile},
+		{key: "auth.jwt_issuer", env: "AUTH_JWT_ISSUER", flag: "auth-jwt-issuer", usage: "required iss claim of bearer JWTs", ptr: &c.Auth.JWTIssuer},
+		{key: "auth.jwt_audience", env: "AUTH_JWT_AUDIENCE", flag: "auth-jwt-audience", usage: "required aud claim of bearer JWTs", ptr: &c.Auth.JWTAudience},
+		{key: "auth.exempt_health", env: "AUTH_EXEMPT_HEALTH", flag: "auth-exempt-health", usage: "let the gRPC health service be called without credentials", ptr: &c.Auth.ExemptHealth},
+		{key: "auth.exempt_reflection", env: "AUTH_EXEMPT_REFLECTION", flag: "auth-exempt-reflection", usage: "let the gRPC reflection service be called without credentials", ptr: &c.Auth.ExemptReflection},
+		{key: "auth.admins", env: "AUTH_ADMINS", flag: "auth-admins", usage: "comma-separated principals allowed to call AdminService, such as api-key:ops,jwt:alice", ptr: &c.Auth.Admins},
+
+		{key: "shutdown.drain_timeout", env: "SHUTDOWN_DRAIN_TIMEOUT", flag: "drain-timeout", usage: "how long in-flight calls may run after SIGINT/SIGTERM", ptr: &c.Shutdown.DrainTimeout},
+
+		{key: "health.check_interval", env: "HEALTH_CHECK_INTERVAL", flag: "health-check-interval", usage: "how often the database is pinged for health checks", ptr: &c.Health.CheckInterval},
+		{key: "health.http_addr", env: "HEALTH_HTTP_ADDR", flag: "health-http-addr", usage: "address serving HTTP /healthz, /readyz and /metrics, disabled when empty", ptr: &c.Health.HTTPAddr},
+
+		{key: "books.page_token_secret", env: "PAGE_TOKEN_SECRET", flag: "page-token-secret", usage: "key signing page tokens, shared by all replicas", secret: true, ptr: &c.Books.PageTokenSecret},
+		{key: "books.deleted_retention", env: "DELETED_BOOK_RETENTION", flag: "deleted-retention", usage: "how long soft-deleted books are kept", ptr: &c.Books.DeletedRetention},
+		{key: "books.purge_interval", env: "PURGE_INTERVAL", flag: "purge-interval", usage: "how often expired deleted books are purged", ptr: &c.Books.PurgeInterval},
+		{key: "books.watch", env: "WATCH_BOOKS", flag: "watch-books", usage: "serve WatchBooks; only for single-replica deployments, as each replica only sees its own writes", ptr: &c.Books.Watch},
+
+		{key: "features.migrate_on_start", env: "MIGRATE_ON_START", flag: "migrate-on-start", usage: "apply pending migrations before serving", ptr: &c.Features.MigrateOnStart},
+		{key: "features.reflection", env: "ENABLE_REFLECTION", flag: "reflection", usage: "register the gRPC reflection service", ptr: &c.Features.Reflection},
+	}
+}
+
+func (s setting) set(value string) error {
+	switch p := s.ptr.(type) {
+	case *string:
+		*p = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected a value such as 30s", value)
+		}
+		*p = d
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %T", s.ptr))
+	}
+	return nil
+}
+
+func (s setting) get() string {
+	switch p := s.ptr.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *time.Duration:
+		return p.String()
+	}
+	panic(fmt.Sprintf("config: unsupported setting type %T", s.ptr))
+}
+
+func (s setting) isBool() bool {
+	_, ok := s.ptr.(*bool)
+	return ok
+}
+
+// sensitiveParams are the connection string parameters holding passwords.
+// lib/pq reads them from the query of a URL as well as from its userinfo.
+var sensitiveParams = map[string]bool{"password": true, "sslpassword": true}
+
+// redacted returns the printable value of s. Connection strings keep
+// everything but their passwords; other secrets only show whether they are
+// set.
+func (s setting) redacted() string {
+	value := s.get()
+	if !s.secret || value == "" {
+		return value
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		u.RawQuery = redactQuery(u.RawQuery)
+		return u.Redacted()
+	}
+	return "REDACTED"
+}
+
+// redactQuery masks the sensitiveParams of a raw URL query, leaving the other
+// parameters and their order as they are.
+func redactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err != nil || sensitiveParams[name] {
+			params[i] = key + "=xxxxx"
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// tag is the YAML tag s is printed with. Durations are strings such as 30s.
+func (s setting) tag() string {
+	switch s.ptr.(type) {
+	case *int:
+		return "!!int"
+	case *bool:
+		return "!!bool"
+	}
+	return "!!str"
+}
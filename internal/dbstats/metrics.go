@@ -0,0 +1,64 @@
+// Package dbstats publishes database connection pool statistics, so that the
+// pool can be sized from what the server actually does.
+package dbstats
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Source is implemented by *sql.DB.
+type Source interface {
+	Stats() sql.DBStats
+}
+
+// metric is one pool statistic. The names are those of the Prometheus Go
+// client's database/sql collector, so existing dashboards can be reused.
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(s sql.DBStats) float64
+}
+
+var metrics = []metric{
+	{"go_sql_max_open_connections", "gauge", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"go_sql_open_connections", "gauge", "The number of established connections both in use and idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"go_sql_in_use_connections", "gauge", "The number of connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"go_sql_idle_connections", "gauge", "The number of idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"go_sql_wait_count_total", "counter", "The total number of connections waited for.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"go_sql_wait_duration_seconds_total", "counter", "The total time blocked waiting for a new connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"go_sql_max_idle_closed_total", "counter", "The total number of connections closed due to max_idle_conns.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"go_sql_max_idle_time_closed_total", "counter", "The total number of connections closed due to conn_max_idle_time.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"go_sql_max_lifetime_closed_total", "counter", "The total number of connections closed due to conn_max_lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+// Handler serves the statistics of db in the Prometheus text format.
+func Handler(db Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w, db.Stats())
+	})
+}
+
+// Write writes stats in the Prometheus text format.
+func Write(w io.Writer, stats sql.DBStats) error {
+	for _, m := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value(stats))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
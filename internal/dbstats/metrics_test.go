@@ -0,0 +1,46 @@
+package dbstats
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedStats sql.DBStats
+
+func (f fixedStats) Stats() sql.DBStats {
+	return sql.DBStats(f)
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(fixedStats{
+		MaxOpenConnections: 25,
+		InUse:              5,
+		Idle:               2,
+		WaitCount:          12,
+		WaitDuration:       1500 * time.Millisecond,
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE go_sql_max_open_connections gauge\ngo_sql_max_open_connections 25\n",
+		"go_sql_in_use_connections 5\n",
+		"go_sql_idle_connections 2\n",
+		"# TYPE go_sql_wait_count_total counter\ngo_sql_wait_count_total 12\n",
+		"go_sql_wait_duration_seconds_total 1.5\n",
+		"go_sql_max_lifetime_closed_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
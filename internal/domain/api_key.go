@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// apiKeyPrefix marks API keys, so that leaked ones are easy to recognise.
+const apiKeyPrefix = "lib_"
+
+// APIKey lets a client authenticate with a static key. Only a hash of the key
+// is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	Hash      string    `db:"key_hash"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// NewAPIKey generates a random key and returns it together with the APIKey
+// to store for it.
+func NewAPIKey(name string) (key string, apiKey *APIKey) {
+	key = apiKeyPrefix + rand.Text()
+	return key, &APIKey{Name: name, Hash: HashAPIKey(key)}
+}
+
+// HashAPIKey returns the hash under which key is stored. Keys are random
+// and long, so a fast unsalted hash is enough to make a stolen table useless
+// and still lets keys be looked up by hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,9 +1,12 @@
 package domain
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Book struct {
@@ -12,16 +15,40 @@ type Book struct {
 	Author    string    `db:"author"`
 	Edition   int       `db:"edition"`
 	ISBN      string    `db:"isbn"`
+	Version   int64     `db:"version"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
+	// DeletedAt is set while the book is soft-deleted.
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// ETag returns the opaque entity tag clients use for optimistic concurrency.
+func (b *Book) ETag() string {
+	return strconv.FormatInt(b.Version, 10)
+}
+
+// ParseETag returns the version encoded in an etag produced by Book.ETag.
+func ParseETag(etag string) (int64, error) {
+	version, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("malformed etag %q", etag)
+	}
+	return version, nil
 }
 
 func BookToDto(book *Book) *v1.Book {
-	return &v1.Book{
-		Id:      book.ID,
-		Title:   book.Title,
-		Author:  book.Author,
-		Edition: int32(book.Edition),
-		Isbn:    book.ISBN,
+	dto := &v1.Book{
+		Id:         book.ID,
+		Title:      book.Title,
+		Author:     book.Author,
+		Edition:    int32(book.Edition),
+		Isbn:       book.ISBN,
+		Etag:       book.ETag(),
+		CreateTime: timestamppb.New(book.CreatedAt),
+		UpdateTime: timestamppb.New(book.UpdatedAt),
+	}
+	if book.DeletedAt != nil {
+		dto.DeleteTime = timestamppb.New(*book.DeletedAt)
 	}
+	return dto
 }
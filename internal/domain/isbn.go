@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// ISBN is a validated ISBN in its canonical form: 13 digits, no separators.
+// ISBN-10 input is converted to the equivalent 978-prefixed ISBN-13.
+type ISBN string
+
+// ParseISBN accepts an ISBN-10 or ISBN-13 with optional hyphens or spaces,
+// verifies its check digit and returns the canonical ISBN-13.
+func ParseISBN(s string) (ISBN, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+
+	switch len(digits) {
+	case 10:
+		if !isDigits(digits[:9]) || !(isDigits(digits[9:]) || digits[9] == 'X') {
+			return "", ErrInvalidISBN
+		}
+		if isbn10CheckDigit(digits[:9]) != digits[9] {
+			return "", ErrInvalidISBN
+		}
+		body := "978" + digits[:9]
+		return ISBN(body + string(isbn13CheckDigit(body))), nil
+	case 13:
+		if !isDigits(digits) || !(strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979")) {
+			return "", ErrInvalidISBN
+		}
+		if isbn13CheckDigit(digits[:12]) != digits[12] {
+			return "", ErrInvalidISBN
+		}
+		return ISBN(digits), nil
+	}
+
+	return "", ErrInvalidISBN
+}
+
+func (i ISBN) String() string {
+	return string(i)
+}
+
+// ISBN10 returns the ISBN-10 form. Only 978-prefixed ISBNs have one.
+func (i ISBN) ISBN10() (string, bool) {
+	if !strings.HasPrefix(string(i), "978") {
+		return "", false
+	}
+	body := string(i)[3:12]
+	return body + string(isbn10CheckDigit(body)), true
+}
+
+// Hyphenated formats the ISBN for display, e.g. "978-0-13-419044-0".
+//
+// Splitting the registrant from the publication element requires the
+// International ISBN Agency range tables. Only the English-language groups
+// 978-0 and 978-1 are known here; other ISBNs are rendered as
+// prefix-body-check, e.g. "979-100000000-9".
+func (i ISBN) Hyphenated() string {
+	s := string(i)
+	if len(s) != 13 {
+		return s
+	}
+
+	prefix, rest, check := s[:3], s[3:12], s[12:]
+	if prefix == "978" {
+		if ranges, ok := registrantRanges[rest[0]]; ok {
+			group, body := rest[:1], rest[1:]
+			for _, r := range ranges {
+				if n := r.length; body[:n] >= r.from && body[:n] <= r.to {
+					return strings.Join([]string{prefix, group, body[:n], body[n:], check}, "-")
+				}
+			}
+		}
+	}
+
+	return strings.Join([]string{prefix, rest, check}, "-")
+}
+
+type registrantRange struct {
+	from, to string
+	length   int
+}
+
+// registrantRanges maps a 978 group digit to its registrant ranges, keyed by
+// the leading digits of the eight digits following the group.
+var registrantRanges = map[byte][]registrantRange{
+	'0': {
+		{"00", "19", 2},
+		{"200", "699", 3},
+		{"7000", "8499", 4},
+		{"85000", "89999", 5},
+		{"900000", "949999", 6},
+		{"9500000", "9999999", 7},
+	},
+	'1': {
+		{"00", "09", 2},
+		{"100", "399", 3},
+		{"4000", "5499", 4},
+		{"55000", "86979", 5},
+		{"869800", "998999", 6},
+		{"9990000", "9999999", 7},
+	},
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isbn10CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+func isbn13CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
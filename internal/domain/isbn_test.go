@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseISBN(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ISBN
+	}{
+		{"978-0134190440", "9780134190440"},
+		{"9780134190440", "9780134190440"},
+		{"0134190440", "9780134190440"},
+		{"0-13-419044-0", "9780134190440"},
+		{"978 0 13 235088 4", "9780132350884"},
+		{"080442957x", "9780804429573"},
+		{"979-10-90636-07-1", "9791090636071"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseISBN(tt.input)
+		if err != nil {
+			t.Errorf("ParseISBN(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseISBN(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseISBN_Invalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"978-0134190441", // bad ISBN-13 check digit
+		"0134190445",     // bad ISBN-10 check digit
+		"977-0134190440", // not a Bookland prefix
+		"X134190440",
+		"97801341904400",
+		"978013419044a",
+	}
+
+	for _, input := range inputs {
+		if _, err := ParseISBN(input); !errors.Is(err, ErrInvalidISBN) {
+			t.Errorf("ParseISBN(%q) error = %v, want ErrInvalidISBN", input, err)
+		}
+	}
+}
+
+func TestISBN_Formats(t *testing.T) {
+	tests := []struct {
+		isbn       ISBN
+		isbn10     string
+		hyphenated string
+	}{
+		{"9780134190440", "0134190440", "978-0-13-419044-0"},
+		{"9780804429573", "080442957X", "978-0-8044-2957-3"},
+		{"9781491950357", "1491950358", "978-1-4919-5035-7"},
+		{"9791090636071", "", "979-109063607-1"},
+	}
+
+	for _, tt := range tests {
+		isbn10, ok := tt.isbn.ISBN10()
+		if ok != (tt.isbn10 != "") || isbn10 != tt.isbn10 {
+			t.Errorf("%s.ISBN10() = %q, %v, want %q", tt.isbn, isbn10, ok, tt.isbn10)
+		}
+		if got := tt.isbn.Hyphenated(); got != tt.hyphenated {
+			t.Errorf("%s.Hyphenated() = %q, want %q", tt.isbn, got, tt.hyphenated)
+		}
+	}
+}
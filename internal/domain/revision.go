@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RevisionAction is the kind of write that produced a BookRevision.
+type RevisionAction string
+
+const (
+	RevisionCreate   RevisionAction = "create"
+	RevisionUpdate   RevisionAction = "update"
+	RevisionDelete   RevisionAction = "delete"
+	RevisionUndelete RevisionAction = "undelete"
+	RevisionPurge    RevisionAction = "purge"
+	RevisionRestore  RevisionAction = "restore"
+	// RevisionSnapshot is the baseline recorded for books that existed
+	// before history was kept.
+	RevisionSnapshot RevisionAction = "snapshot"
+)
+
+// BookRevision is an entry in a book's audit history. It holds the book's
+// values right after the write; Revision equals the book's Version then.
+type BookRevision struct {
+	BookID    string         `db:"book_id"`
+	Revision  int64          `db:"revision"`
+	Action    RevisionAction `db:"action"`
+	Actor     string         `db:"actor"`
+	Title     string         `db:"title"`
+	Author    string         `db:"author"`
+	Edition   int            `db:"edition"`
+	ISBN      string         `db:"isbn"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+var revisionActions = map[RevisionAction]v1.BookRevision_Action{
+	RevisionCreate:   v1.BookRevision_CREATE,
+	RevisionUpdate:   v1.BookRevision_UPDATE,
+	RevisionDelete:   v1.BookRevision_DELETE,
+	RevisionUndelete: v1.BookRevision_UNDELETE,
+	RevisionPurge:    v1.BookRevision_PURGE,
+	RevisionRestore:  v1.BookRevision_RESTORE,
+	RevisionSnapshot: v1.BookRevision_SNAPSHOT,
+}
+
+// RevisionToDto converts rev, listing the fields that changed since
+// previous. previous is nil for the first revision of a book.
+func RevisionToDto(rev, previous *BookRevision) *v1.BookRevision {
+	if previous == nil {
+		previous = &BookRevision{}
+	}
+
+	dto := &v1.BookRevision{
+		BookId:     rev.BookID,
+		Revision:   rev.Revision,
+		Action:     revisionActions[rev.Action],
+		Actor:      rev.Actor,
+		CreateTime: timestamppb.New(rev.CreatedAt),
+		Book: &v1.Book{
+			Id:      rev.BookID,
+			Title:   rev.Title,
+			Author:  rev.Author,
+			Edition: int32(rev.Edition),
+			Isbn:    rev.ISBN,
+		},
+	}
+
+	change := func(field, old, new string) {
+		if old != new {
+			dto.Changes = append(dto.Changes, &v1.FieldChange{Field: field, OldValue: old, NewValue: new})
+		}
+	}
+	change("title", previous.Title, rev.Title)
+	change("author", previous.Author, rev.Author)
+	change("edition", editionString(previous.Edition), editionString(rev.Edition))
+	change("isbn", previous.ISBN, rev.ISBN)
+
+	return dto
+}
+
+func editionString(edition int) string {
+	if edition == 0 {
+		return ""
+	}
+	return strconv.Itoa(edition)
+}
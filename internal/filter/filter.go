@@ -0,0 +1,225 @@
+// Package filter parses AIP-160 style filter expressions and AIP-132 style
+// order_by clauses into a small AST that storage backends translate into
+// their own query language.
+//
+// The supported filter grammar is a subset of AIP-160:
+//
+//	expression = sequence { "AND" sequence }
+//	sequence   = factor { factor }            (implicit AND)
+//	factor     = term { "OR" term }
+//	term       = [ "NOT" ] simple
+//	simple     = restriction | "(" expression ")"
+//	restriction = field comparator value
+//	comparator = "=" | "!=" | "<" | "<=" | ">" | ">="
+//
+// As in AIP-160, OR binds tighter than AND.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the value type of a filterable field.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Timestamp
+)
+
+// Schema lists the fields that may appear in a filter or order_by clause.
+type Schema map[string]Type
+
+// Operator is a comparison operator.
+type Operator string
+
+const (
+	Equal          Operator = "="
+	NotEqual       Operator = "!="
+	Less           Operator = "<"
+	LessOrEqual    Operator = "<="
+	Greater        Operator = ">"
+	GreaterOrEqual Operator = ">="
+)
+
+// Expr is a node of a parsed filter expression.
+type Expr interface {
+	expr()
+}
+
+// And matches when both operands match.
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches when either operand matches.
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches when its operand does not.
+type Not struct {
+	Expr Expr
+}
+
+// Comparison compares a field against a literal. Value is a string, int64 or
+// time.Time depending on the field's Type in the schema.
+type Comparison struct {
+	Field string
+	Op    Operator
+	Value any
+}
+
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+func (Comparison) expr() {}
+
+// Order is a single field of an order_by clause.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+// MaxDepth is how deeply parentheses may nest in a filter expression. The
+// parser recurses once per level, so without a limit a single long filter
+// could exhaust the stack.
+const MaxDepth = 64
+
+// Parse parses a filter expression, validating field names and literal types
+// against schema. An empty or blank input yields a nil Expr.
+func Parse(input string, schema Schema) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	return expr, nil
+}
+
+// ParseOrderBy parses a comma separated list of fields, each optionally
+// followed by "asc" or "desc", e.g. "author, edition desc".
+func ParseOrderBy(input string, schema Schema) ([]Order, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	var orders []Order
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(input, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid order_by term %q", strings.TrimSpace(part))
+		}
+
+		order := Order{Field: words[0]}
+		if _, ok := schema[order.Field]; !ok {
+			return nil, fmt.Errorf("unknown field %q", order.Field)
+		}
+		if seen[order.Field] {
+			return nil, fmt.Errorf("field %q specified more than once", order.Field)
+		}
+		seen[order.Field] = true
+
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				order.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q for field %q", words[1], order.Field)
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// Match evaluates expr against a record whose field values are returned by
+// value. A nil expr matches everything.
+func Match(expr Expr, value func(field string) any) bool {
+	switch e := expr.(type) {
+	case nil:
+		return true
+	case And:
+		return Match(e.Left, value) && Match(e.Right, value)
+	case Or:
+		return Match(e.Left, value) || Match(e.Right, value)
+	case Not:
+		return !Match(e.Expr, value)
+	case Comparison:
+		c := Compare(value(e.Field), e.Value)
+		switch e.Op {
+		case Equal:
+			return c == 0
+		case NotEqual:
+			return c != 0
+		case Less:
+			return c < 0
+		case LessOrEqual:
+			return c <= 0
+		case Greater:
+			return c > 0
+		case GreaterOrEqual:
+			return c >= 0
+		}
+	}
+	return false
+}
+
+// Compare orders two field values of the same type, returning -1, 0 or 1.
+func Compare(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	panic(fmt.Sprintf("filter: cannot compare values of type %T", a))
+}
+
+// FormatValue renders a field value in the literal syntax accepted by
+// ParseValue.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ParseValue parses text as a literal for field.
+func (s Schema) ParseValue(field, text string) (any, error) {
+	typ, ok := s[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+	return parseValue(typ, token{kind: tokenText, text: text})
+}
@@ -0,0 +1,173 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	"title":       String,
+	"author":      String,
+	"edition":     Int,
+	"create_time": Timestamp,
+}
+
+func TestParse(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  Expr
+	}{
+		{"", nil},
+		{`author = "Donovan"`, Comparison{Field: "author", Op: Equal, Value: "Donovan"}},
+		{`author=Donovan`, Comparison{Field: "author", Op: Equal, Value: "Donovan"}},
+		{`edition >= -1`, Comparison{Field: "edition", Op: GreaterOrEqual, Value: int64(-1)}},
+		{`create_time > "2024-01-02T03:04:05Z"`, Comparison{Field: "create_time", Op: Greater, Value: created}},
+		{
+			`author = "Donovan" AND edition > 1`,
+			And{
+				Left:  Comparison{Field: "author", Op: Equal, Value: "Donovan"},
+				Right: Comparison{Field: "edition", Op: Greater, Value: int64(1)},
+			},
+		},
+		{
+			// OR binds tighter than AND.
+			`edition = 1 AND edition = 2 OR edition = 3`,
+			And{
+				Left: Comparison{Field: "edition", Op: Equal, Value: int64(1)},
+				Right: Or{
+					Left:  Comparison{Field: "edition", Op: Equal, Value: int64(2)},
+					Right: Comparison{Field: "edition", Op: Equal, Value: int64(3)},
+				},
+			},
+		},
+		{
+			`NOT (title != 'a' edition < 2)`,
+			Not{Expr: And{
+				Left:  Comparison{Field: "title", Op: NotEqual, Value: "a"},
+				Right: Comparison{Field: "edition", Op: Less, Value: int64(2)},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input, testSchema)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !equalExpr(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	inputs := []string{
+		`publisher = "x"`,
+		`author =`,
+		`author "x"`,
+		`edition > "1"`,
+		`edition > one`,
+		`create_time > yesterday`,
+		`(author = "x"`,
+		`author = "x")`,
+		`author = "x`,
+		`author ! "x"`,
+		`AND author = "x"`,
+		`author = "x" OR`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(input, testSchema); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestParse_Depth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + `author = "x"` + strings.Repeat(")", depth)
+	}
+
+	if _, err := Parse(nested(MaxDepth), testSchema); err != nil {
+		t.Errorf("Expected %d levels of parentheses to parse, got %v", MaxDepth, err)
+	}
+	if _, err := Parse(nested(MaxDepth+1), testSchema); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("Expected an error about nesting, got %v", err)
+	}
+	// Siblings do not add up.
+	sibling := nested(MaxDepth) + " AND " + nested(MaxDepth)
+	if _, err := Parse(sibling, testSchema); err != nil {
+		t.Errorf("Expected sibling groups to parse, got %v", err)
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	orders, err := ParseOrderBy("title, edition desc ,author ASC", testSchema)
+	if err != nil {
+		t.Fatalf("ParseOrderBy failed: %v", err)
+	}
+
+	want := []Order{{Field: "title"}, {Field: "edition", Desc: true}, {Field: "author"}}
+	if len(orders) != len(want) {
+		t.Fatalf("ParseOrderBy returned %v, want %v", orders, want)
+	}
+	for i := range want {
+		if orders[i] != want[i] {
+			t.Errorf("order %d = %v, want %v", i, orders[i], want[i])
+		}
+	}
+
+	for _, input := range []string{"publisher", "title,", "title up", "title, title desc", "title desc extra"} {
+		if _, err := ParseOrderBy(input, testSchema); err == nil {
+			t.Errorf("ParseOrderBy(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	record := map[string]any{"title": "Go", "author": "Donovan", "edition": int64(2)}
+	value := func(field string) any { return record[field] }
+
+	tests := map[string]bool{
+		`author = "Donovan"`:                    true,
+		`author = "Donovan" AND edition > 2`:    false,
+		`author = "Kernighan" OR edition >= 2`:  true,
+		`NOT author = "Donovan"`:                false,
+		`title < "Java" edition != 1`:           true,
+		`(edition = 1 OR edition = 3) title=Go`: false,
+	}
+
+	for input, want := range tests {
+		expr, err := Parse(input, testSchema)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", input, err)
+		}
+		if got := Match(expr, value); got != want {
+			t.Errorf("Match(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func equalExpr(a, b Expr) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case And:
+		b, ok := b.(And)
+		return ok && equalExpr(a.Left, b.Left) && equalExpr(a.Right, b.Right)
+	case Or:
+		b, ok := b.(Or)
+		return ok && equalExpr(a.Left, b.Left) && equalExpr(a.Right, b.Right)
+	case Not:
+		b, ok := b.(Not)
+		return ok && equalExpr(a.Expr, b.Expr)
+	case Comparison:
+		b, ok := b.(Comparison)
+		return ok && a.Field == b.Field && a.Op == b.Op && Compare(a.Value, b.Value) == 0
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenComparator
+	tokenString
+	tokenText
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenText && t.text == keyword
+}
+
+func isTextRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-:+", r)
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '=' || r == '<' || r == '>' || r == '!':
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, start)
+			}
+			tokens = append(tokens, token{kind: tokenComparator, text: op, pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string starting at position %d", start)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					sb.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case isTextRune(r):
+			start := i
+			for i < len(runes) && isTextRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenText, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+	// depth is how many parentheses enclose the current position.
+	depth int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseExpression() (Expr, error) {
+	left, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		right, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseSequence() (Expr, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.kind == tokenRParen || tok.isKeyword("AND") {
+			return left, nil
+		}
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseTerm() (Expr, error) {
+	if p.peek().isKeyword("NOT") {
+		p.next()
+		expr, err := p.parseSimple()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+	return p.parseSimple()
+}
+
+func (p *parser) parseSimple() (Expr, error) {
+	tok := p.next()
+
+	if tok.kind == tokenLParen {
+		if p.depth == MaxDepth {
+			return nil, fmt.Errorf("parentheses nested deeper than %d levels at position %d", MaxDepth, tok.pos)
+		}
+		p.depth++
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at position %d, got %s", closing.pos, closing)
+		}
+		p.depth--
+		return expr, nil
+	}
+
+	if tok.kind != tokenText || tok.isKeyword("AND") || tok.isKeyword("OR") || tok.isKeyword("NOT") {
+		return nil, fmt.Errorf("expected field name at position %d, got %s", tok.pos, tok)
+	}
+
+	typ, ok := p.schema[tok.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", tok.text)
+	}
+
+	op := p.next()
+	if op.kind != tokenComparator {
+		return nil, fmt.Errorf("expected comparator after %q at position %d, got %s", tok.text, op.pos, op)
+	}
+
+	lit := p.next()
+	if lit.kind != tokenString && lit.kind != tokenText {
+		return nil, fmt.Errorf("expected value for %q at position %d, got %s", tok.text, lit.pos, lit)
+	}
+
+	value, err := parseValue(typ, lit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %q: %w", tok.text, err)
+	}
+
+	return Comparison{Field: tok.text, Op: Operator(op.text), Value: value}, nil
+}
+
+func parseValue(typ Type, lit token) (any, error) {
+	switch typ {
+	case Int:
+		if lit.kind != tokenText {
+			return nil, fmt.Errorf("expected integer, got string %s", lit)
+		}
+		n, err := strconv.ParseInt(lit.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected integer, got %s", lit)
+		}
+		return n, nil
+	case Timestamp:
+		t, err := time.Parse(time.RFC3339Nano, lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("expected RFC 3339 timestamp, got %s", lit)
+		}
+		return t, nil
+	default:
+		return lit.text, nil
+	}
+}
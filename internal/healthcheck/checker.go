@@ -0,0 +1,108 @@
+// Package healthcheck keeps the gRPC health service in step with the
+// database and exposes the same status to HTTP probes.
+package healthcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// maxPingTimeout bounds a single database ping.
+const maxPingTimeout = 5 * time.Second
+
+// Pinger is implemented by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Checker pings the database and reports services SERVING while it answers
+// and NOT_SERVING while it does not. The overall server status, the empty
+// service name, follows the same rule.
+type Checker struct {
+	server   *health.Server
+	db       Pinger
+	services []string
+	serving  bool
+}
+
+// NewChecker returns a Checker updating the status of services on server.
+func NewChecker(server *health.Server, db Pinger, services ...string) *Checker {
+	return &Checker{
+		server:   server,
+		db:       db,
+		services: append([]string{""}, services...),
+		serving:  true,
+	}
+}
+
+// Run checks the database every interval until ctx is done, starting right
+// away.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx, min(interval, maxPingTimeout))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings the database once, waiting at most timeout, and updates the
+// status of every service. Transitions are logged.
+func (c *Checker) Check(ctx context.Context, timeout time.Duration) {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := c.db.PingContext(pingCtx)
+	if ctx.Err() != nil {
+		// Shutting down; the ping failing says nothing about the database.
+		return
+	}
+
+	serving := err == nil
+	switch {
+	case serving && !c.serving:
+		slog.Info("Database is reachable again, reporting SERVING")
+	case !serving && c.serving:
+		slog.Error("Database ping failed, reporting NOT_SERVING", "error", err)
+	}
+	c.serving = serving
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+// Handler serves HTTP probes for clients that cannot speak gRPC health
+// checking. /healthz answers 200 while the process is up; /readyz answers 200
+// only while service is SERVING, so it also fails once the server starts
+// draining.
+func Handler(server *health.Server, service string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		resp, err := server.Check(r.Context(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
@@ -0,0 +1,86 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const testService = "library.v1.LibraryService"
+
+type fakePinger struct {
+	err error
+}
+
+func (f *fakePinger) PingContext(context.Context) error {
+	return f.err
+}
+
+func servingStatus(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Check(%q) failed: %v", service, err)
+	}
+	return resp.Status
+}
+
+func readyz(handler http.Handler) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return rec.Code
+}
+
+func TestChecker(t *testing.T) {
+	server := health.NewServer()
+	db := &fakePinger{}
+	checker := NewChecker(server, db, testService)
+	handler := Handler(server, testService)
+	ctx := context.Background()
+
+	checker.Check(ctx, time.Second)
+	for _, service := range []string{"", testService} {
+		if got := servingStatus(t, server, service); got != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected %q SERVING, got %v", service, got)
+		}
+	}
+	if code := readyz(handler); code != http.StatusOK {
+		t.Errorf("Expected /readyz 200, got %d", code)
+	}
+
+	db.err = errors.New("connection refused")
+	checker.Check(ctx, time.Second)
+	for _, service := range []string{"", testService} {
+		if got := servingStatus(t, server, service); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected %q NOT_SERVING, got %v", service, got)
+		}
+	}
+	if code := readyz(handler); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz 503, got %d", code)
+	}
+
+	db.err = nil
+	checker.Check(ctx, time.Second)
+	if got := servingStatus(t, server, testService); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected recovery to SERVING, got %v", got)
+	}
+
+	// Once the server drains, probes fail whatever the database says.
+	server.Shutdown()
+	checker.Check(ctx, time.Second)
+	if code := readyz(handler); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz 503 after shutdown, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz 200 while the process is up, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Cockroach runs each migration on its own, since CockroachDB does not allow
+// every schema change inside a transaction. Migrators are serialised by a row
+// lock held in a separate transaction, which the database drops if the
+// holder's connection dies, so a crashed migrator never leaves it behind.
+var Cockroach = Dialect{lock: lockCockroach}
+
+// SQLite runs all pending migrations in one immediate transaction. That takes
+// the database's write lock, serialising migrators, and rolls every step back
+// if one fails.
+var SQLite = Dialect{lock: lockSQLite}
+
+func lockCockroach(ctx context.Context, db *sql.DB) (querier, func(error) error, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations_lock (id INT8 PRIMARY KEY)`); err != nil {
+		return nil, nil, err
+	}
+	if _, err := db.ExecContext(ctx, `UPSERT INTO schema_migrations_lock (id) VALUES (1)`); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM schema_migrations_lock WHERE id = 1 FOR UPDATE`); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	release := func(error) error {
+		return tx.Rollback()
+	}
+	return db, release, nil
+}
+
+func lockSQLite(ctx context.Context, db *sql.DB) (querier, func(error) error, error) {
+	// The repository/sqlite DSN makes every transaction immediate.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release := func(err error) error {
+		if err != nil {
+			return tx.Rollback()
+		}
+		return tx.Commit()
+	}
+	return tx, release, nil
+}
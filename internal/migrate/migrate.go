@@ -0,0 +1,269 @@
+// Package migrate applies the SQL migrations embedded in the server binary.
+//
+// Migrations are files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql. The applied version is kept in a
+// schema_migrations table laid out like the one the golang-migrate CLI
+// uses, so databases migrated with either tool can be handed to the other.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrDirty is returned when a previous migration failed half-way. The schema
+// has to be repaired by hand before migrating again.
+var ErrDirty = errors.New("database is dirty")
+
+// Migration is one schema change and the statements that undo it.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Dialect adapts the Migrator to a database engine.
+type Dialect struct {
+	// lock blocks until no other migrator holds the lock, then returns where
+	// to run migrations and a function releasing the lock. release commits
+	// the migrations when err is nil, where the engine runs them in a
+	// transaction.
+	lock func(ctx context.Context, db *sql.DB) (q querier, release func(err error) error, err error)
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations found at the root of fsys.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load reads the migrations at the root of fsys, ordered by version. Every
+// version must have an up file; a missing down file leaves Down empty.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected a .up.sql or .down.sql suffix", name)
+		}
+		prefix, title, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version %q", name, prefix)
+		}
+
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration and returns how many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(q querier) error {
+		current, err := m.version(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := m.apply(ctx, q, migration, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations and returns how many it
+// reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(q querier) error {
+		current, err := m.version(ctx, q)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, q, migration, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Version returns the version of the latest applied migration, zero when
+// none is, and whether a migration failed half-way.
+func (m *Migrator) Version(ctx context.Context) (version uint64, dirty bool, err error) {
+	if err := m.createTable(ctx, m.db); err != nil {
+		return 0, false, err
+	}
+	return m.readVersion(ctx, m.db)
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration, Applied: migration.Version <= current}
+	}
+	return statuses, nil
+}
+
+// locked runs fn while holding the migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func(q querier) error) error {
+	q, release, err := m.dialect.lock(ctx, m.db)
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	err = fn(q)
+	if releaseErr := release(err); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// version returns the current version, refusing to go on from a dirty one.
+func (m *Migrator) version(ctx context.Context, q querier) (uint64, error) {
+	if err := m.createTable(ctx, q); err != nil {
+		return 0, err
+	}
+	version, dirty, err := m.readVersion(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d, repair the schema and reset schema_migrations", ErrDirty, version)
+	}
+	return version, nil
+}
+
+// apply runs one direction of migration and then records version. The
+// database is marked dirty at the migration's version while the script runs,
+// so a failure part-way through is noticed by the next migrator.
+func (m *Migrator) apply(ctx context.Context, q querier, migration Migration, script string, version uint64) error {
+	if err := m.setVersion(ctx, q, migration.Version, true); err != nil {
+		return err
+	}
+	if strings.TrimSpace(script) != "" {
+		if _, err := q.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, q, version, false)
+}
+
+func (m *Migrator) createTable(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INT8 NOT NULL PRIMARY KEY, dirty BOOL NOT NULL)`)
+	return err
+}
+
+func (m *Migrator) readVersion(ctx context.Context, q querier) (uint64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := q.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(version), dirty, nil
+}
+
+// setVersion replaces the single schema_migrations row. Version zero with a
+// clean schema leaves the table empty, as golang-migrate does.
+func (m *Migrator) setVersion(ctx context.Context, q querier, version uint64, dirty bool) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	_, err := q.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, int64(version), dirty)
+	return err
+}
@@ -0,0 +1,260 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlite"
+	"github.com/igoventura/go-grpc-library-service/migrations"
+)
+
+func newSQLiteMigrator(t *testing.T) *Migrator {
+	t.Helper()
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, SQLite, migrations.SQLite())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return m
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2_add_b.up.sql":      {Data: []byte("B")},
+		"1_create_a.up.sql":   {Data: []byte("A")},
+		"1_create_a.down.sql": {Data: []byte("-A")},
+		"README.md":           {Data: []byte("ignored")},
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "create_a", Up: "A", Down: "-A"},
+		{Version: 2, Name: "add_b", Up: "B"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d migrations, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Migration %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	if _, err := Load(fstest.MapFS{"1_a.down.sql": {Data: []byte("-A")}}); err == nil {
+		t.Error("Expected an error for a migration without an up file")
+	}
+	if _, err := Load(fstest.MapFS{"one_a.up.sql": {Data: []byte("A")}}); err == nil {
+		t.Error("Expected an error for a non-numeric version")
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	ctx := context.Background()
+	latest := m.migrations[len(m.migrations)-1].Version
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if applied != len(m.migrations) {
+		t.Errorf("Expected %d migrations applied, got %d", len(m.migrations), applied)
+	}
+	if version, dirty, err := m.Version(ctx); err != nil || version != latest || dirty {
+		t.Errorf("Expected clean version %d, got %d (dirty %v, err %v)", latest, version, dirty, err)
+	}
+
+	// Up is idempotent.
+	if applied, err := m.Up(ctx); err != nil || applied != 0 {
+		t.Errorf("Expected nothing left to apply, got %d (err %v)", applied, err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("Down(1) reverted %d, err %v", reverted, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for i, status := range statuses {
+		if wantApplied := i < len(statuses)-1; status.Applied != wantApplied {
+			t.Errorf("Migration %d_%s: expected applied %v", status.Version, status.Name, wantApplied)
+		}
+	}
+
+	// Every down migration really undoes its up migration.
+	if reverted, err := m.Down(ctx, len(m.migrations)); err != nil || reverted != len(m.migrations)-1 {
+		t.Fatalf("Down(all) reverted %d, err %v", reverted, err)
+	}
+	if version, _, err := m.Version(ctx); err != nil || version != 0 {
+		t.Errorf("Expected version 0, got %d (err %v)", version, err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Errorf("Up after a full Down failed: %v", err)
+	}
+}
+
+func TestMigrator_FailedMigration(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	ctx := context.Background()
+	m.migrations = append(m.migrations, Migration{Version: 99990101000000, Name: "broken", Up: "NOT SQL"})
+
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatal("Expected Up to fail")
+	}
+
+	// SQLite rolls the whole run back rather than leaving it dirty.
+	version, dirty, err := m.Version(ctx)
+	if err != nil || version != 0 || dirty {
+		t.Errorf("Expected clean version 0, got %d (dirty %v, err %v)", version, dirty, err)
+	}
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	ctx := context.Background()
+
+	if err := m.createTable(ctx, m.db); err != nil {
+		t.Fatalf("createTable failed: %v", err)
+	}
+	if err := m.setVersion(ctx, m.db, m.migrations[0].Version, true); err != nil {
+		t.Fatalf("setVersion failed: %v", err)
+	}
+
+	if _, err := m.Up(ctx); !errors.Is(err, ErrDirty) {
+		t.Errorf("Expected ErrDirty, got %v", err)
+	}
+}
+
+// upTo applies the migrations up to and including version.
+func upTo(t *testing.T, m *Migrator, version uint64) {
+	t.Helper()
+	all := m.migrations
+	defer func() { m.migrations = all }()
+
+	for i, migration := range all {
+		if migration.Version > version {
+			m.migrations = all[:i]
+			break
+		}
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("Up to %d failed: %v", version, err)
+	}
+}
+
+func TestMigrations_CanonicalizeISBN(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	ctx := context.Background()
+	upTo(t, m, 20261016090000)
+
+	// Invalid ISBNs are left alone rather than guessed at.
+	tests := []struct{ stored, want string }{
+		{"978-0134190440", "9780134190440"},
+		{"9780134190440", "9780134190440"},
+		{"0134190440", "9780134190440"},
+		{"0-8044-2957-x", "9780804429573"},
+		{"979 10 00000 00 8", "9791000000008"},
+		{"0134190445", "0134190445"},
+		{"978-0134190441", "978-0134190441"},
+		{"not an ISBN", "not an ISBN"},
+	}
+	for i, tt := range tests {
+		_, err := m.db.ExecContext(ctx, `INSERT INTO books (id, title, author, edition, isbn) VALUES ($1, 'Title', 'Author', $2, $3)`,
+			fmt.Sprint(i), i+1, tt.stored)
+		if err != nil {
+			t.Fatalf("failed to insert book: %v", err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	for i, tt := range tests {
+		var got string
+		if err := m.db.QueryRowContext(ctx, `SELECT isbn FROM books WHERE id = $1`, fmt.Sprint(i)).Scan(&got); err != nil {
+			t.Fatalf("failed to read book: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("ISBN %q: expected %q, got %q", tt.stored, tt.want, got)
+		}
+		// The migration must agree with the service on what is canonical.
+		if canonical, err := domain.ParseISBN(tt.stored); err == nil && canonical.String() != tt.want {
+			t.Errorf("ISBN %q: ParseISBN returns %q, the test expects %q", tt.stored, canonical, tt.want)
+		}
+	}
+}
+
+func TestMigrations_DuplicateBooks(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	ctx := context.Background()
+	upTo(t, m, 20261016090000)
+
+	// The first two only collide once their ISBNs are canonical.
+	books := []struct {
+		id, isbn, createdAt string
+		edition             int
+	}{
+		{"b", "978-0134190440", "2025-01-02 00:00:00+00:00", 1},
+		{"a", "0134190440", "2025-01-01 00:00:00+00:00", 1},
+		{"c", "9780134190440", "2025-01-03 00:00:00+00:00", 1},
+		{"d", "9780134190440", "2025-01-04 00:00:00+00:00", 2},
+	}
+	for _, b := range books {
+		_, err := m.db.ExecContext(ctx, `INSERT INTO books (id, title, author, edition, isbn, created_at) VALUES ($1, 'Title', 'Author', $2, $3, $4)`,
+			b.id, b.edition, b.isbn, b.createdAt)
+		if err != nil {
+			t.Fatalf("failed to insert book: %v", err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	var kept []string
+	rows, err := m.db.QueryContext(ctx, `SELECT id FROM books ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to list books: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		kept = append(kept, id)
+	}
+	rows.Close()
+	if fmt.Sprint(kept) != "[a d]" {
+		t.Errorf("Expected the oldest book of each edition to be kept, got %v", kept)
+	}
+
+	moved := make(map[string]string)
+	rows, err = m.db.QueryContext(ctx, `SELECT id, kept_id FROM books_duplicates`)
+	if err != nil {
+		t.Fatalf("failed to list duplicates: %v", err)
+	}
+	for rows.Next() {
+		var id, keptID string
+		rows.Scan(&id, &keptID)
+		moved[id] = keptID
+	}
+	rows.Close()
+	if len(moved) != 2 || moved["b"] != "a" || moved["c"] != "a" {
+		t.Errorf("Expected b and c to be set aside for a, got %v", moved)
+	}
+}
@@ -0,0 +1,23 @@
+package repository
+
+import "context"
+
+// SystemActor is recorded for writes made without an actor in the context,
+// such as the background purger.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are attributed to actor in the
+// book history.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or SystemActor.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+)
+
+// APIKeyRepository stores the API keys clients authenticate with.
+type APIKeyRepository interface {
+	// CreateAPIKey stores key and returns it with its ID and creation time
+	// set. It reports ErrAlreadyExists when the name or hash is taken.
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error)
+	// GetAPIKeyByHash returns the key stored under hash, or ErrNotFound.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	// ListAPIKeys returns every key, ordered by name.
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	// DeleteAPIKey revokes the key with the given name, or reports
+	// ErrNotFound.
+	DeleteAPIKey(ctx context.Context, name string) error
+}
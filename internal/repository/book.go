@@ -3,16 +3,161 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"iter"
+	"time"
 
 	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
 )
 
+// BookRepository stores books. Single-book lookups (GetBookByID,
+// GetBookByISBN and GetBooksByIDs) return soft-deleted books too, with
+// DeletedAt set; listings only do so when asked to.
 type BookRepository interface {
 	CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error)
 	GetBookByID(ctx context.Context, id string) (*domain.Book, error)
-	UpdateBook(ctx context.Context, book *domain.Book) (*domain.Book, error)
-	DeleteBook(ctx context.Context, id string) error
-	ListBooks(ctx context.Context) ([]*domain.Book, error)
+	// GetBookByISBN returns every edition stored under a canonical ISBN,
+	// ordered by edition, or ErrNotFound when there is none.
+	GetBookByISBN(ctx context.Context, isbn string) ([]*domain.Book, error)
+	// UpdateBook writes only the given fields of book, which must be a subset
+	// of BookMutableFields, and returns the stored book. A non-zero version
+	// makes the write conditional on the stored version; book.Version is
+	// ignored. Soft-deleted books cannot be updated and report ErrNotFound.
+	UpdateBook(ctx context.Context, book *domain.Book, fields []string, version int64) (*domain.Book, error)
+	// DeleteBook soft-deletes a book. A non-zero version makes the delete
+	// conditional on the stored version. Deleting a book that is already
+	// deleted reports ErrNotFound.
+	DeleteBook(ctx context.Context, id string, version int64) error
+	// UndeleteBook restores a soft-deleted book, or reports ErrNotDeleted
+	// when it is not deleted.
+	UndeleteBook(ctx context.Context, id string, version int64) (*domain.Book, error)
+	// PurgeBook permanently removes a soft-deleted book, or reports
+	// ErrNotDeleted when it is not deleted.
+	PurgeBook(ctx context.Context, id string, version int64) error
+	// PurgeDeletedBooks permanently removes up to limit of the books
+	// soft-deleted before the given time, longest deleted first, in one
+	// transaction and returns their IDs. Callers purge a large backlog by
+	// calling it until it returns fewer than limit IDs.
+	PurgeDeletedBooks(ctx context.Context, before time.Time, limit int) ([]string, error)
+	ListBooks(ctx context.Context, params ListBooksParams) ([]*domain.Book, error)
+	// StreamBooks is ListBooks without buffering: books are yielded one at a
+	// time while the underlying result set is read. Iteration stops at the
+	// first error.
+	StreamBooks(ctx context.Context, params ListBooksParams) iter.Seq2[*domain.Book, error]
+	// CountBooks returns how many books match params.Filter and
+	// params.ShowDeleted. The paging fields are ignored.
+	CountBooks(ctx context.Context, params ListBooksParams) (int, error)
+
+	// CreateBooks inserts all books in one transaction: either every book is
+	// stored or none is.
+	CreateBooks(ctx context.Context, books []*domain.Book) ([]*domain.Book, error)
+	// GetBooksByIDs returns the books that exist among ids, in no particular
+	// order.
+	GetBooksByIDs(ctx context.Context, ids []string) ([]*domain.Book, error)
+	// DeleteBooks soft-deletes the books with the given ids in one
+	// transaction and returns the IDs it deleted. Unless partial is set, a
+	// missing or already deleted id fails the whole call with ErrNotFound and
+	// nothing is deleted.
+	DeleteBooks(ctx context.Context, ids []string, partial bool) ([]string, error)
+
+	// Every write above records a domain.BookRevision in the same
+	// transaction, attributed to ActorFromContext(ctx).
+
+	// ListBookRevisions returns up to limit revisions of a book with a
+	// revision number of at least from, oldest first. History outlives the
+	// book, so revisions of purged books are still returned.
+	ListBookRevisions(ctx context.Context, bookID string, from int64, limit int) ([]*domain.BookRevision, error)
+	// RestoreBookRevision sets the mutable fields of a book back to the values
+	// recorded in one of its revisions. The book must not be deleted. A
+	// non-zero version makes the restore conditional on the stored version.
+	RestoreBookRevision(ctx context.Context, bookID string, revision, version int64) (*domain.Book, error)
 }
 
-var ErrNotFound = errors.New("not found")
+// Fields of a book that can be used in filter expressions and order_by.
+const (
+	FieldTitle      = "title"
+	FieldAuthor     = "author"
+	FieldEdition    = "edition"
+	FieldISBN       = "isbn"
+	FieldCreateTime = "create_time"
+	FieldUpdateTime = "update_time"
+)
+
+// BookMutableFields are the fields UpdateBook can change.
+var BookMutableFields = []string{FieldTitle, FieldAuthor, FieldEdition, FieldISBN}
+
+// BookFilterSchema describes the filterable and sortable book fields.
+var BookFilterSchema = filter.Schema{
+	FieldTitle:      filter.String,
+	FieldAuthor:     filter.String,
+	FieldEdition:    filter.Int,
+	FieldISBN:       filter.String,
+	FieldCreateTime: filter.Timestamp,
+	FieldUpdateTime: filter.Timestamp,
+}
+
+// BookFieldValue returns the value of a BookFilterSchema field, typed as
+// the filter package expects.
+func BookFieldValue(book *domain.Book, field string) any {
+	switch field {
+	case FieldTitle:
+		return book.Title
+	case FieldAuthor:
+		return book.Author
+	case FieldEdition:
+		return int64(book.Edition)
+	case FieldISBN:
+		return book.ISBN
+	case FieldCreateTime:
+		return book.CreatedAt
+	case FieldUpdateTime:
+		return book.UpdatedAt
+	}
+	return nil
+}
+
+// ListBooksParams controls a single keyset-paginated ListBooks call.
+// Books are sorted by OrderBy with the ID as the final tie-breaker, so the
+// ordering is total and stable across pages.
+type ListBooksParams struct {
+	Filter  filter.Expr
+	OrderBy []filter.Order
+	// PageSize limits the number of books returned. Zero means no limit.
+	PageSize int
+	// After, when set, restricts the result to books sorting after the cursor.
+	After *Cursor
+	// ShowDeleted includes soft-deleted books.
+	ShowDeleted bool
+}
+
+// Cursor identifies the position of the last book of a previous page.
+// Values holds that book's values for each OrderBy field, in order.
+type Cursor struct {
+	Values []any
+	ID     string
+}
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionMismatch = errors.New("version mismatch")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrNotDeleted      = errors.New("not deleted")
+	// ErrRetriesExhausted is returned when a transaction kept conflicting
+	// with concurrent writers and was given up on. Retrying later may succeed.
+	ErrRetriesExhausted = errors.New("transaction retries exhausted")
+)
+
+// ConflictError is returned when a write would give a book the same ISBN and
+// edition as another one. It matches ErrAlreadyExists with errors.Is.
+type ConflictError struct {
+	ExistingID string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("book %s already has this ISBN and edition", e.ExistingID)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrAlreadyExists
+}
@@ -0,0 +1,128 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlquery"
+	"github.com/lib/pq"
+)
+
+func (r *BookRepository) CreateBooks(ctx context.Context, books []*domain.Book) ([]*domain.Book, error) {
+	if len(books) == 0 {
+		return books, nil
+	}
+
+	b := &sqlquery.Builder{}
+	values := make([]string, len(books))
+	for i, book := range books {
+		values[i] = fmt.Sprintf("(%s, %s, %s, %s)", b.Arg(book.Title), b.Arg(book.Author), b.Arg(book.Edition), b.Arg(book.ISBN))
+	}
+
+	type key struct {
+		isbn    string
+		edition int
+	}
+	byKey := make(map[key]*domain.Book, len(books))
+	for _, book := range books {
+		byKey[key{book.ISBN, book.Edition}] = book
+	}
+
+	// RETURNING makes no ordering promise, so rows are matched back to their
+	// books through the unique (isbn, edition) pair.
+	stmt := `INSERT INTO books (title, author, edition, isbn) VALUES ` + strings.Join(values, ", ") +
+		` RETURNING id, isbn, edition, version, created_at, updated_at`
+
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, b.Args...)
+		if err != nil {
+			return batchError(err)
+		}
+
+		for rows.Next() {
+			var k key
+			var inserted domain.Book
+			if err := rows.Scan(&inserted.ID, &k.isbn, &k.edition, &inserted.Version, &inserted.CreatedAt, &inserted.UpdatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			book := byKey[k]
+			book.ID, book.Version, book.CreatedAt, book.UpdatedAt = inserted.ID, inserted.Version, inserted.CreatedAt, inserted.UpdatedAt
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return batchError(err)
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionCreate, books...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+func (r *BookRepository) GetBooksByIDs(ctx context.Context, ids []string) ([]*domain.Book, error) {
+	rows, err := r.db.QueryContext(ctx, selectBooks+` WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	return scanBooks(rows)
+}
+
+func (r *BookRepository) DeleteBooks(ctx context.Context, ids []string, partial bool) ([]string, error) {
+	stmt := `UPDATE books SET deleted_at = now(), version = version + 1, updated_at = now() WHERE id = ANY($1) AND deleted_at IS NULL RETURNING ` + bookProjection
+
+	var books []*domain.Book
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+
+		if books, err = scanBooks(rows); err != nil {
+			return err
+		}
+
+		if !partial && len(books) != len(uniqueIDs(ids)) {
+			return repository.ErrNotFound
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionDelete, books...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]string, len(books))
+	for i, book := range books {
+		deleted[i] = book.ID
+	}
+
+	return deleted, nil
+}
+
+// batchError maps a unique violation in a multi-row write to
+// ErrAlreadyExists. Unlike single-row writes, the conflicting row cannot be
+// attributed to one book of the batch.
+func batchError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %s", repository.ErrAlreadyExists, pqErr.Detail)
+	}
+	return err
+}
+
+func uniqueIDs(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
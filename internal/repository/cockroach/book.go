@@ -3,11 +3,27 @@ package cockroach
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
 
 	"github.com/igoventura/go-grpc-library-service/internal/domain"
 	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlquery"
+	"github.com/lib/pq"
 )
 
+// bookProjection lists the columns scanned by scanBook.
+const bookProjection = `id, title, author, edition, isbn, version, created_at, updated_at, deleted_at`
+
+// selectBooks is the common projection scanned by scanBooks.
+const selectBooks = `SELECT ` + bookProjection + ` FROM books`
+
+// uniqueViolation is the SQLSTATE reported when a unique index rejects a write.
+const uniqueViolation = "23505"
+
 type BookRepository struct {
 	repository.BookRepository
 
@@ -21,35 +37,27 @@ func NewBookRepository(db *sql.DB) repository.BookRepository {
 }
 
 func (r *BookRepository) CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-
-	if err != nil {
-		return nil, err
-	}
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		stmt := `INSERT INTO books (title, author, edition, isbn) VALUES ($1, $2, $3, $4) RETURNING id, version, created_at, updated_at`
+		row := tx.QueryRowContext(ctx, stmt, book.Title, book.Author, book.Edition, book.ISBN)
 
-	defer tx.Rollback()
-
-	stmt := `INSERT INTO books (title, author, edition, isbn) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
-	row := tx.QueryRowContext(ctx, stmt, book.Title, book.Author, book.Edition, book.ISBN)
-
-	err = row.Scan(&book.ID, &book.CreatedAt, &book.UpdatedAt)
+		if err := row.Scan(&book.ID, &book.Version, &book.CreatedAt, &book.UpdatedAt); err != nil {
+			return translateUniqueViolation(ctx, tx, err, book)
+		}
 
+		return recordRevisions(ctx, tx, domain.RevisionCreate, book)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-
 	return book, nil
 }
 
 func (r *BookRepository) GetBookByID(ctx context.Context, id string) (*domain.Book, error) {
-	stmt := `SELECT id, title, author, edition, isbn, created_at, updated_at FROM books WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, stmt, id)
+	row := r.db.QueryRowContext(ctx, selectBooks+` WHERE id = $1`, id)
 	book := &domain.Book{}
-	err := row.Scan(&book.ID, &book.Title, &book.Author, &book.Edition, &book.ISBN, &book.CreatedAt, &book.UpdatedAt)
+	err := scanBook(row, book)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -60,72 +68,301 @@ func (r *BookRepository) GetBookByID(ctx context.Context, id string) (*domain.Bo
 	return book, nil
 }
 
-func (r *BookRepository) UpdateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
+func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn string) ([]*domain.Book, error) {
+	// Served by books_isbn_edition_key, whose leading column is isbn.
+	rows, err := r.db.QueryContext(ctx, selectBooks+` WHERE isbn = $1 ORDER BY edition`, isbn)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
-
-	stmt := `UPDATE books SET title = $1, author = $2, edition = $3, isbn = $4, updated_at = now() WHERE id = $5 RETURNING updated_at`
-	err = tx.QueryRowContext(ctx, stmt, book.Title, book.Author, book.Edition, book.ISBN, book.ID).Scan(&book.UpdatedAt)
 
+	books, err := scanBooks(rows)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, repository.ErrNotFound
-		}
 		return nil, err
 	}
+	if len(books) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return books, nil
+}
+
+func (r *BookRepository) UpdateBook(ctx context.Context, book *domain.Book, fields []string, version int64) (*domain.Book, error) {
+	b := &sqlquery.Builder{}
+	var assignments []string
+	for _, field := range fields {
+		var value any
+		switch field {
+		case repository.FieldTitle:
+			value = book.Title
+		case repository.FieldAuthor:
+			value = book.Author
+		case repository.FieldEdition:
+			value = book.Edition
+		case repository.FieldISBN:
+			value = book.ISBN
+		default:
+			return nil, fmt.Errorf("field %q cannot be updated", field)
+		}
+		assignments = append(assignments, sqlquery.BookColumns[field]+" = "+b.Arg(value))
+	}
+	if len(assignments) == 0 {
+		return nil, errors.New("no fields to update")
+	}
 
-	if err := tx.Commit(); err != nil {
+	stmt := `UPDATE books SET ` + strings.Join(assignments, ", ") + `, version = version + 1, updated_at = now() WHERE deleted_at IS NULL AND id = ` + b.Arg(book.ID)
+	if version != 0 {
+		stmt += ` AND version = ` + b.Arg(version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	updated := &domain.Book{}
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		if err := scanBook(tx.QueryRowContext(ctx, stmt, b.Args...), updated); err != nil {
+			if err == sql.ErrNoRows {
+				return r.missingOrConflict(ctx, tx, book.ID, false)
+			}
+			return translateUniqueViolation(ctx, tx, err, book)
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionUpdate, updated)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return book, nil
+	return updated, nil
+}
+
+func (r *BookRepository) DeleteBook(ctx context.Context, id string, version int64) error {
+	stmt := `UPDATE books SET deleted_at = now(), version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`
+	args := []any{id}
+	if version != 0 {
+		stmt += ` AND version = $2`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	return ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		book := &domain.Book{}
+		if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+			if err == sql.ErrNoRows {
+				return r.missingOrConflict(ctx, tx, id, false)
+			}
+			return err
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionDelete, book)
+	})
 }
 
-func (r *BookRepository) DeleteBook(ctx context.Context, id string) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+func (r *BookRepository) UndeleteBook(ctx context.Context, id string, version int64) (*domain.Book, error) {
+	// The unique index on (isbn, edition) covers deleted books as well, so
+	// restoring one can never collide with another book.
+	stmt := `UPDATE books SET deleted_at = NULL, version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NOT NULL`
+	args := []any{id}
+	if version != 0 {
+		stmt += ` AND version = $2`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	book := &domain.Book{}
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+			if err == sql.ErrNoRows {
+				return r.missingOrConflict(ctx, tx, id, true)
+			}
+			return err
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionUndelete, book)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer tx.Rollback()
-	stmt := `DELETE FROM books WHERE id = $1`
-	res, err := tx.ExecContext(ctx, stmt, id)
+	return book, nil
+}
+
+func (r *BookRepository) PurgeBook(ctx context.Context, id string, version int64) error {
+	stmt := `DELETE FROM books WHERE id = $1 AND deleted_at IS NOT NULL`
+	args := []any{id}
+	if version != 0 {
+		stmt += ` AND version = $2`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	return ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		book := &domain.Book{}
+		if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+			if err == sql.ErrNoRows {
+				return r.missingOrConflict(ctx, tx, id, true)
+			}
+			return err
+		}
+
+		return recordPurges(ctx, tx, book)
+	})
+}
+
+func (r *BookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	var books []*domain.Book
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		// Served by books_deleted_at_idx.
+		stmt := `DELETE FROM books WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2 RETURNING ` + bookProjection
+		rows, err := tx.QueryContext(ctx, stmt, before, limit)
+		if err != nil {
+			return err
+		}
+		if books, err = scanBooks(rows); err != nil {
+			return err
+		}
+
+		return recordPurges(ctx, tx, books...)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return ids, nil
+}
+
+// recordPurges records the removal of books. The row is gone, so the purge
+// takes the revision number after the book's last version.
+func recordPurges(ctx context.Context, tx *sql.Tx, books ...*domain.Book) error {
+	for _, book := range books {
+		book.Version++
+	}
+	return recordRevisions(ctx, tx, domain.RevisionPurge, books...)
+}
+
+// missingOrConflict explains why a write on id touched no rows. deleted is
+// the state the write required the book to be in: writes to live books treat
+// a deleted one as missing, while undelete and purge report ErrNotDeleted
+// for a live one. When the state matched, the version must have differed.
+func (r *BookRepository) missingOrConflict(ctx context.Context, tx *sql.Tx, id string, deleted bool) error {
+	var isDeleted bool
+	err := tx.QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM books WHERE id = $1`, id).Scan(&isDeleted)
+	switch {
+	case err == sql.ErrNoRows:
 		return repository.ErrNotFound
+	case err != nil:
+		return err
+	case isDeleted == deleted:
+		return repository.ErrVersionMismatch
+	case deleted:
+		return repository.ErrNotDeleted
 	}
+	return repository.ErrNotFound
+}
 
-	if err := tx.Commit(); err != nil {
+// translateUniqueViolation turns a unique_violation on (isbn, edition) into a
+// repository.ConflictError naming the book that already holds the pair.
+// The failed statement aborted tx, so it is first rolled back to the restart
+// savepoint ExecuteTx set. Looking the owner up in tx rather than on another
+// pooled connection keeps a small pool from running dry while every
+// conflicting writer holds one connection and waits for a second.
+func translateUniqueViolation(ctx context.Context, tx *sql.Tx, err error, book *domain.Book) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
 		return err
 	}
-	return nil
+
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+restartSavepoint); err != nil {
+		return repository.ErrAlreadyExists
+	}
+	var existingID string
+	lookup := `SELECT id FROM books WHERE isbn = $1 AND edition = $2`
+	if err := tx.QueryRowContext(ctx, lookup, book.ISBN, book.Edition).Scan(&existingID); err != nil {
+		return repository.ErrAlreadyExists
+	}
+	return &repository.ConflictError{ExistingID: existingID}
 }
 
-func (r *BookRepository) ListBooks(ctx context.Context) ([]*domain.Book, error) {
-	rows, err := r.db.QueryContext(ctx, `SELECT id, title, author, edition, isbn, created_at, updated_at FROM books`)
+func (r *BookRepository) ListBooks(ctx context.Context, params repository.ListBooksParams) ([]*domain.Book, error) {
+	stmt, args, err := sqlquery.ListStatement(selectBooks, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, err
 	}
+
+	return scanBooks(rows)
+}
+
+// StreamBooks yields books as they are read from the result set, so memory
+// use stays flat however many rows match. Cancelling ctx aborts the query.
+func (r *BookRepository) StreamBooks(ctx context.Context, params repository.ListBooksParams) iter.Seq2[*domain.Book, error] {
+	return func(yield func(*domain.Book, error) bool) {
+		stmt, args, err := sqlquery.ListStatement(selectBooks, params)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		rows, err := r.db.QueryContext(ctx, stmt, args...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			book := &domain.Book{}
+			if err := scanBook(rows, book); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(book, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// scanBooks reads every row of a selectBooks query and closes rows.
+func scanBooks(rows *sql.Rows) ([]*domain.Book, error) {
 	defer rows.Close()
 	var books []*domain.Book
 	for rows.Next() {
 		var book domain.Book
-		err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Edition, &book.ISBN, &book.CreatedAt, &book.UpdatedAt)
-		if err != nil {
+		if err := scanBook(rows, &book); err != nil {
 			return nil, err
 		}
 		books = append(books, &book)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return books, nil
 }
+
+func (r *BookRepository) CountBooks(ctx context.Context, params repository.ListBooksParams) (int, error) {
+	stmt, args, err := sqlquery.CountStatement(params)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, stmt, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// scanBook reads the selectBooks projection from a single row.
+func scanBook(row interface{ Scan(dest ...any) error }, book *domain.Book) error {
+	return row.Scan(&book.ID, &book.Title, &book.Author, &book.Edition, &book.ISBN, &book.Version, &book.CreatedAt, &book.UpdatedAt, &book.DeletedAt)
+}
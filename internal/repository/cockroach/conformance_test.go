@@ -0,0 +1,90 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/migrate"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/repositorytest"
+	"github.com/igoventura/go-grpc-library-service/migrations"
+	_ "github.com/lib/pq"
+)
+
+// TestConformance runs the shared suite in a scratch database created next
+// to the one DATABASE_URL points at, so existing data is never touched.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+
+	db := openScratchDatabase(t, dsn)
+	repositorytest.RunConformance(t, func(t *testing.T) repository.BookRepository {
+		if _, err := db.Exec("TRUNCATE book_revisions, books"); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+		return NewBookRepository(db)
+	})
+}
+
+func TestAPIKeyConformance(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+
+	db := openScratchDatabase(t, dsn)
+	repositorytest.RunAPIKeyConformance(t, func(t *testing.T) repository.APIKeyRepository {
+		if _, err := db.Exec("TRUNCATE api_keys"); err != nil {
+			t.Fatalf("failed to truncate api_keys: %v", err)
+		}
+		return NewAPIKeyRepository(db)
+	})
+}
+
+func openScratchDatabase(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	name := fmt.Sprintf("library_conformance_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP DATABASE " + name + " CASCADE"); err != nil {
+			t.Errorf("failed to drop database %s: %v", name, err)
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse DATABASE_URL: %v", err)
+	}
+	u.Path = "/" + name
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// Closed before the database is dropped.
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.Cockroach, migrations.Cockroach())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,100 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlquery"
+)
+
+// revisionChunkSize bounds the rows per INSERT so large purges stay under
+// the bind parameter limit.
+const revisionChunkSize = 1000
+
+// recordRevisions appends a revision for each book as it is after the write.
+// It must run in the transaction that made the write so the history can
+// never disagree with the books table.
+func recordRevisions(ctx context.Context, tx *sql.Tx, action domain.RevisionAction, books ...*domain.Book) error {
+	actor := repository.ActorFromContext(ctx)
+
+	for start := 0; start < len(books); start += revisionChunkSize {
+		chunk := books[start:min(start+revisionChunkSize, len(books))]
+
+		b := &sqlquery.Builder{}
+		values := make([]string, len(chunk))
+		for i, book := range chunk {
+			values[i] = fmt.Sprintf("(%s, %s, %s, %s, %s, %s, %s, %s)",
+				b.Arg(book.ID), b.Arg(book.Version), b.Arg(string(action)), b.Arg(actor),
+				b.Arg(book.Title), b.Arg(book.Author), b.Arg(book.Edition), b.Arg(book.ISBN))
+		}
+
+		stmt := `INSERT INTO book_revisions (book_id, revision, action, actor, title, author, edition, isbn) VALUES ` + strings.Join(values, ", ")
+		if _, err := tx.ExecContext(ctx, stmt, b.Args...); err != nil {
+			return fmt.Errorf("record revision: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *BookRepository) ListBookRevisions(ctx context.Context, bookID string, from int64, limit int) ([]*domain.BookRevision, error) {
+	stmt := `SELECT book_id, revision, action, actor, title, author, edition, isbn, created_at FROM book_revisions
+		WHERE book_id = $1 AND revision >= $2 ORDER BY revision LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, stmt, bookID, from, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*domain.BookRevision
+	for rows.Next() {
+		var rev domain.BookRevision
+		if err := rows.Scan(&rev.BookID, &rev.Revision, &rev.Action, &rev.Actor, &rev.Title, &rev.Author, &rev.Edition, &rev.ISBN, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *BookRepository) RestoreBookRevision(ctx context.Context, bookID string, revision, version int64) (*domain.Book, error) {
+	restored := &domain.Book{}
+	err := ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		book := &domain.Book{ID: bookID}
+		lookup := `SELECT title, author, edition, isbn FROM book_revisions WHERE book_id = $1 AND revision = $2`
+		if err := tx.QueryRowContext(ctx, lookup, bookID, revision).Scan(&book.Title, &book.Author, &book.Edition, &book.ISBN); err != nil {
+			if err == sql.ErrNoRows {
+				return repository.ErrNotFound
+			}
+			return err
+		}
+
+		stmt := `UPDATE books SET title = $2, author = $3, edition = $4, isbn = $5, version = version + 1, updated_at = now()
+			WHERE id = $1 AND deleted_at IS NULL`
+		args := []any{bookID, book.Title, book.Author, book.Edition, book.ISBN}
+		if version != 0 {
+			stmt += ` AND version = $6`
+			args = append(args, version)
+		}
+		stmt += ` RETURNING ` + bookProjection
+
+		if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), restored); err != nil {
+			if err == sql.ErrNoRows {
+				return r.missingOrConflict(ctx, tx, bookID, false)
+			}
+			return translateUniqueViolation(ctx, tx, err, book)
+		}
+
+		return recordRevisions(ctx, tx, domain.RevisionRestore, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -0,0 +1,100 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/lib/pq"
+)
+
+// serializationFailure is the SQLSTATE CockroachDB reports when a transaction
+// lost a conflict with a concurrent one and must be retried.
+const serializationFailure = "40001"
+
+// restartSavepoint is the savepoint name CockroachDB recognises for client-side
+// transaction retries.
+const restartSavepoint = "cockroach_restart"
+
+// Retry limits for ExecuteTx. The delay before attempt n is drawn at random
+// from [0, min(maxRetryBackoff, baseRetryBackoff*2^n)).
+const (
+	maxTxAttempts    = 5
+	baseRetryBackoff = 10 * time.Millisecond
+	maxRetryBackoff  = time.Second
+)
+
+// ExecuteTx runs fn in a transaction and commits it, retrying fn when
+// CockroachDB reports a serialization failure. It follows the savepoint
+// protocol: the transaction keeps its priority across attempts, so it
+// eventually wins against the writers it conflicts with.
+//
+// fn may run several times and must not keep side effects of a failed
+// attempt. Once maxTxAttempts attempts have failed, the error wraps
+// repository.ErrRetriesExhausted.
+func ExecuteTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+restartSavepoint); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(tx)
+		if err == nil {
+			// A serialization failure can surface at release time as well.
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+restartSavepoint)
+		}
+		if err == nil {
+			return tx.Commit()
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+		if attempt == maxTxAttempts {
+			return fmt.Errorf("%w after %d attempts: %v", repository.ErrRetriesExhausted, attempt, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+restartSavepoint); err != nil {
+			return err
+		}
+		if err := sleep(ctx, retryBackoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// isRetryable reports whether err is a serialization failure.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == serializationFailure
+}
+
+// retryBackoff returns the delay before retrying after the given attempt,
+// with full jitter so that conflicting clients spread out.
+func retryBackoff(attempt int) time.Duration {
+	ceiling := min(maxRetryBackoff, baseRetryBackoff<<attempt)
+	return rand.N(ceiling)
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+func (r *BookRepository) CreateBooks(ctx context.Context, books []*domain.Book) ([]*domain.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type key struct {
+		isbn    string
+		edition int
+	}
+	seen := make(map[key]bool, len(books))
+	for _, book := range books {
+		k := key{book.ISBN, book.Edition}
+		if err := r.conflict(book); err != nil || seen[k] {
+			return nil, fmt.Errorf("%w: isbn %s, edition %d", repository.ErrAlreadyExists, book.ISBN, book.Edition)
+		}
+		seen[k] = true
+	}
+
+	for _, book := range books {
+		r.insert(ctx, book)
+	}
+	return books, nil
+}
+
+func (r *BookRepository) GetBooksByIDs(ctx context.Context, ids []string) ([]*domain.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var books []*domain.Book
+	for id := range uniqueIDs(ids) {
+		if book, ok := r.books[id]; ok {
+			books = append(books, cloneBook(book))
+		}
+	}
+	return books, nil
+}
+
+func (r *BookRepository) DeleteBooks(ctx context.Context, ids []string, partial bool) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var live []*domain.Book
+	for id := range uniqueIDs(ids) {
+		if book, ok := r.books[id]; ok && book.DeletedAt == nil {
+			live = append(live, book)
+		} else if !partial {
+			return nil, repository.ErrNotFound
+		}
+	}
+
+	deleted := make([]string, len(live))
+	for i, book := range live {
+		r.softDelete(ctx, book)
+		deleted[i] = book.ID
+	}
+	return deleted, nil
+}
+
+func uniqueIDs(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,383 @@
+// Package memory is a BookRepository kept in process memory. It mirrors the
+// CockroachDB repository's behaviour, including its errors, and is meant for
+// local runs, demos and tests; nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+type BookRepository struct {
+	mu        sync.RWMutex
+	books     map[string]*domain.Book
+	revisions map[string][]*domain.BookRevision
+	now       func() time.Time
+}
+
+// Option configures a BookRepository.
+type Option func(*BookRepository)
+
+// WithClock replaces time.Now as the source of the stored timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(r *BookRepository) {
+		r.now = now
+	}
+}
+
+func NewBookRepository(opts ...Option) repository.BookRepository {
+	r := &BookRepository{
+		books:     make(map[string]*domain.Book),
+		revisions: make(map[string][]*domain.BookRevision),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// timestamp returns the current time at the microsecond precision
+// CockroachDB stores.
+func (r *BookRepository) timestamp() time.Time {
+	return r.now().UTC().Truncate(time.Microsecond)
+}
+
+func (r *BookRepository) CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.conflict(book); err != nil {
+		return nil, err
+	}
+	r.insert(ctx, book)
+	return book, nil
+}
+
+// insert stores a new book, filling in the fields the database would
+// generate. The caller holds the write lock and has checked for conflicts.
+func (r *BookRepository) insert(ctx context.Context, book *domain.Book) {
+	now := r.timestamp()
+	book.ID = uuid.NewString()
+	book.Version = 1
+	book.CreatedAt = now
+	book.UpdatedAt = now
+	book.DeletedAt = nil
+
+	r.books[book.ID] = cloneBook(book)
+	r.record(ctx, domain.RevisionCreate, book)
+}
+
+func (r *BookRepository) GetBookByID(ctx context.Context, id string) (*domain.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	book, ok := r.books[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return cloneBook(book), nil
+}
+
+func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn string) ([]*domain.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var books []*domain.Book
+	for _, book := range r.books {
+		if book.ISBN == isbn {
+			books = append(books, cloneBook(book))
+		}
+	}
+	if len(books) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].Edition < books[j].Edition })
+	return books, nil
+}
+
+func (r *BookRepository) UpdateBook(ctx context.Context, book *domain.Book, fields []string, version int64) (*domain.Book, error) {
+	for _, field := range fields {
+		if !slices.Contains(repository.BookMutableFields, field) {
+			return nil, fmt.Errorf("field %q cannot be updated", field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(book.ID, version, false)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := cloneBook(stored)
+	for _, field := range fields {
+		switch field {
+		case repository.FieldTitle:
+			updated.Title = book.Title
+		case repository.FieldAuthor:
+			updated.Author = book.Author
+		case repository.FieldEdition:
+			updated.Edition = book.Edition
+		case repository.FieldISBN:
+			updated.ISBN = book.ISBN
+		}
+	}
+	if err := r.conflict(updated); err != nil {
+		return nil, err
+	}
+
+	r.write(ctx, domain.RevisionUpdate, updated)
+	return cloneBook(updated), nil
+}
+
+func (r *BookRepository) DeleteBook(ctx context.Context, id string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(id, version, false)
+	if err != nil {
+		return err
+	}
+	r.softDelete(ctx, stored)
+	return nil
+}
+
+func (r *BookRepository) softDelete(ctx context.Context, stored *domain.Book) {
+	deleted := cloneBook(stored)
+	now := r.timestamp()
+	deleted.DeletedAt = &now
+	r.write(ctx, domain.RevisionDelete, deleted)
+}
+
+func (r *BookRepository) UndeleteBook(ctx context.Context, id string, version int64) (*domain.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(id, version, true)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := cloneBook(stored)
+	restored.DeletedAt = nil
+	r.write(ctx, domain.RevisionUndelete, restored)
+	return cloneBook(restored), nil
+}
+
+func (r *BookRepository) PurgeBook(ctx context.Context, id string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(id, version, true)
+	if err != nil {
+		return err
+	}
+	r.purge(ctx, stored)
+	return nil
+}
+
+func (r *BookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []*domain.Book
+	for _, book := range r.books {
+		if book.DeletedAt != nil && book.DeletedAt.Before(before) {
+			expired = append(expired, book)
+		}
+	}
+	slices.SortFunc(expired, func(a, b *domain.Book) int {
+		return a.DeletedAt.Compare(*b.DeletedAt)
+	})
+
+	var purged []string
+	for _, book := range expired[:min(limit, len(expired))] {
+		r.purge(ctx, book)
+		purged = append(purged, book.ID)
+	}
+	return purged, nil
+}
+
+// purge removes a book for good. Like the database, it records the purge
+// under the revision number after the book's last version.
+func (r *BookRepository) purge(ctx context.Context, stored *domain.Book) {
+	purged := cloneBook(stored)
+	purged.Version++
+	delete(r.books, stored.ID)
+	r.record(ctx, domain.RevisionPurge, purged)
+}
+
+// write stores a changed copy of a book, bumping its version and update
+// time, and records the revision. The caller holds the write lock.
+func (r *BookRepository) write(ctx context.Context, action domain.RevisionAction, book *domain.Book) {
+	book.Version++
+	book.UpdatedAt = r.timestamp()
+	r.books[book.ID] = cloneBook(book)
+	r.record(ctx, action, book)
+}
+
+// find returns the stored book with id when it is in the deleted state the
+// write requires and, for a non-zero version, at that version. Otherwise it
+// explains the mismatch the same way the CockroachDB repository does.
+func (r *BookRepository) find(id string, version int64, deleted bool) (*domain.Book, error) {
+	stored, ok := r.books[id]
+	switch {
+	case !ok:
+		return nil, repository.ErrNotFound
+	case (stored.DeletedAt != nil) != deleted:
+		if deleted {
+			return nil, repository.ErrNotDeleted
+		}
+		return nil, repository.ErrNotFound
+	case version != 0 && version != stored.Version:
+		return nil, repository.ErrVersionMismatch
+	}
+	return stored, nil
+}
+
+// conflict reports a ConflictError when another book, deleted or not,
+// already has book's ISBN and edition.
+func (r *BookRepository) conflict(book *domain.Book) error {
+	for _, other := range r.books {
+		if other.ID != book.ID && other.ISBN == book.ISBN && other.Edition == book.Edition {
+			return &repository.ConflictError{ExistingID: other.ID}
+		}
+	}
+	return nil
+}
+
+func (r *BookRepository) ListBooks(ctx context.Context, params repository.ListBooksParams) ([]*domain.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(params)
+}
+
+// StreamBooks takes a snapshot of the matching books and yields it without
+// holding the lock, so a slow consumer never blocks writers.
+func (r *BookRepository) StreamBooks(ctx context.Context, params repository.ListBooksParams) iter.Seq2[*domain.Book, error] {
+	return func(yield func(*domain.Book, error) bool) {
+		books, err := r.ListBooks(ctx, params)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for _, book := range books {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(book, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *BookRepository) CountBooks(ctx context.Context, params repository.ListBooksParams) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, book := range r.books {
+		if matches(params, book) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// list applies params the way the SQL built by the CockroachDB repository
+// does: filter, keyset cursor, order with id as the final tie-breaker, then
+// limit. The caller holds the lock.
+func (r *BookRepository) list(params repository.ListBooksParams) ([]*domain.Book, error) {
+	for _, order := range params.OrderBy {
+		if _, ok := repository.BookFilterSchema[order.Field]; !ok {
+			return nil, fmt.Errorf("unknown field %q", order.Field)
+		}
+	}
+	if params.After != nil && len(params.After.Values) != len(params.OrderBy) {
+		return nil, fmt.Errorf("cursor has %d values for %d order fields", len(params.After.Values), len(params.OrderBy))
+	}
+
+	var books []*domain.Book
+	for _, book := range r.books {
+		if !matches(params, book) {
+			continue
+		}
+		if params.After != nil && compare(params.OrderBy, book, params.After.Values, params.After.ID) <= 0 {
+			continue
+		}
+		books = append(books, book)
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		return compare(params.OrderBy, books[i], sortValues(params.OrderBy, books[j]), books[j].ID) < 0
+	})
+	if params.PageSize > 0 && len(books) > params.PageSize {
+		books = books[:params.PageSize]
+	}
+
+	for i, book := range books {
+		books[i] = cloneBook(book)
+	}
+	return books, nil
+}
+
+func matches(params repository.ListBooksParams, book *domain.Book) bool {
+	if book.DeletedAt != nil && !params.ShowDeleted {
+		return false
+	}
+	return filter.Match(params.Filter, func(field string) any { return repository.BookFieldValue(book, field) })
+}
+
+// compare orders book against the position given by values and id.
+func compare(orders []filter.Order, book *domain.Book, values []any, id string) int {
+	for i, order := range orders {
+		c := filter.Compare(repository.BookFieldValue(book, order.Field), values[i])
+		if order.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(book.ID, id)
+}
+
+func sortValues(orders []filter.Order, book *domain.Book) []any {
+	values := make([]any, len(orders))
+	for i, order := range orders {
+		values[i] = repository.BookFieldValue(book, order.Field)
+	}
+	return values
+}
+
+// cloneBook returns a deep copy, so callers never share memory with the
+// stored books.
+func cloneBook(book *domain.Book) *domain.Book {
+	copied := *book
+	if book.DeletedAt != nil {
+		deletedAt := *book.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	return &copied
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+func TestBookRepository_ConcurrentWrites(t *testing.T) {
+	repo := NewBookRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			book, err := repo.CreateBook(ctx, &domain.Book{Title: "Title", Author: "Author", Edition: i + 1, ISBN: "9780134190440"})
+			if err != nil {
+				t.Errorf("CreateBook failed: %v", err)
+				return
+			}
+			if _, err := repo.UpdateBook(ctx, &domain.Book{ID: book.ID, Title: fmt.Sprintf("Title %d", i)}, []string{repository.FieldTitle}, 0); err != nil {
+				t.Errorf("UpdateBook failed: %v", err)
+			}
+			if _, err := repo.ListBooks(ctx, repository.ListBooksParams{}); err != nil {
+				t.Errorf("ListBooks failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	books, err := repo.GetBookByISBN(ctx, "9780134190440")
+	if err != nil {
+		t.Fatalf("GetBookByISBN failed: %v", err)
+	}
+	if len(books) != 50 {
+		t.Fatalf("Expected 50 editions, got %d", len(books))
+	}
+	for _, book := range books {
+		if _, err := uuid.Parse(book.ID); err != nil {
+			t.Errorf("Expected a UUID, got %q", book.ID)
+		}
+		if book.Version != 2 {
+			t.Errorf("Expected version 2 after one update, got %d", book.Version)
+		}
+	}
+}
+
+func TestBookRepository_ReturnsCopies(t *testing.T) {
+	repo := NewBookRepository()
+	ctx := context.Background()
+
+	created, err := repo.CreateBook(ctx, &domain.Book{Title: "Title", Author: "Author", Edition: 1, ISBN: "9780134190440"})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	created.Title = "Changed by the caller"
+
+	if err := repo.DeleteBook(ctx, created.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	fetched, err := repo.GetBookByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetBookByID failed: %v", err)
+	}
+	if fetched.Title != "Title" {
+		t.Errorf("Expected the stored title to be untouched, got %q", fetched.Title)
+	}
+
+	*fetched.DeletedAt = fetched.DeletedAt.AddDate(-1, 0, 0)
+	again, _ := repo.GetBookByID(ctx, created.ID)
+	if again.DeletedAt.Equal(*fetched.DeletedAt) {
+		t.Error("Expected DeletedAt to be copied, not shared")
+	}
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.BookRepository {
+		return NewBookRepository()
+	})
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+// record appends a revision holding book as it is after the write. The
+// caller holds the write lock.
+func (r *BookRepository) record(ctx context.Context, action domain.RevisionAction, book *domain.Book) {
+	r.revisions[book.ID] = append(r.revisions[book.ID], &domain.BookRevision{
+		BookID:    book.ID,
+		Revision:  book.Version,
+		Action:    action,
+		Actor:     repository.ActorFromContext(ctx),
+		Title:     book.Title,
+		Author:    book.Author,
+		Edition:   book.Edition,
+		ISBN:      book.ISBN,
+		CreatedAt: r.timestamp(),
+	})
+}
+
+func (r *BookRepository) ListBookRevisions(ctx context.Context, bookID string, from int64, limit int) ([]*domain.BookRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var revisions []*domain.BookRevision
+	for _, rev := range r.revisions[bookID] {
+		if len(revisions) == limit {
+			break
+		}
+		if rev.Revision >= from {
+			copied := *rev
+			revisions = append(revisions, &copied)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *BookRepository) RestoreBookRevision(ctx context.Context, bookID string, revision, version int64) (*domain.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var target *domain.BookRevision
+	for _, rev := range r.revisions[bookID] {
+		if rev.Revision == revision {
+			target = rev
+			break
+		}
+	}
+	if target == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	stored, err := r.find(bookID, version, false)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := cloneBook(stored)
+	restored.Title, restored.Author, restored.Edition, restored.ISBN = target.Title, target.Author, target.Edition, target.ISBN
+	if err := r.conflict(restored); err != nil {
+		return nil, err
+	}
+
+	r.write(ctx, domain.RevisionRestore, restored)
+	return cloneBook(restored), nil
+}
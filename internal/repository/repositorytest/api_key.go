@@ -0,0 +1,121 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+// APIKeyFactory returns an empty API key repository. It is called once per
+// subtest.
+type APIKeyFactory func(t *testing.T) repository.APIKeyRepository
+
+// RunAPIKeyConformance runs the shared APIKeyRepository suite against the
+// repositories returned by newRepository.
+func RunAPIKeyConformance(t *testing.T, newRepository APIKeyFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.APIKeyRepository)
+	}{
+		{"CreateAPIKey", testCreateAPIKey},
+		{"GetAPIKeyByHash", testGetAPIKeyByHash},
+		{"ListAPIKeys", testListAPIKeys},
+		{"DeleteAPIKey", testDeleteAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepository(t))
+		})
+	}
+}
+
+func createAPIKey(t *testing.T, repo repository.APIKeyRepository, name string) *domain.APIKey {
+	t.Helper()
+	_, key := domain.NewAPIKey(name)
+	created, err := repo.CreateAPIKey(context.Background(), key)
+	if err != nil {
+		t.Fatalf("CreateAPIKey(%q) failed: %v", name, err)
+	}
+	return created
+}
+
+func testCreateAPIKey(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+	key := createAPIKey(t, repo, "ci")
+
+	if key.ID == "" {
+		t.Error("Expected an ID to be assigned")
+	}
+	if key.CreatedAt.Before(before) {
+		t.Errorf("Expected a fresh creation time, got %v", key.CreatedAt)
+	}
+
+	_, sameName := domain.NewAPIKey("ci")
+	if _, err := repo.CreateAPIKey(ctx, sameName); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a duplicate name, got %v", err)
+	}
+	sameHash := &domain.APIKey{Name: "other", Hash: key.Hash}
+	if _, err := repo.CreateAPIKey(ctx, sameHash); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a duplicate hash, got %v", err)
+	}
+}
+
+func testGetAPIKeyByHash(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	created := createAPIKey(t, repo, "ci")
+
+	got, err := repo.GetAPIKeyByHash(ctx, created.Hash)
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash failed: %v", err)
+	}
+	if got.ID != created.ID || got.Name != "ci" || !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected %+v, got %+v", created, got)
+	}
+
+	if _, err := repo.GetAPIKeyByHash(ctx, domain.HashAPIKey("unknown")); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func testListAPIKeys(t *testing.T, repo repository.APIKeyRepository) {
+	keys, err := repo.ListAPIKeys(context.Background())
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v (%v)", keys, err)
+	}
+
+	for _, name := range []string{"deploy", "backup", "ci"} {
+		createAPIKey(t, repo, name)
+	}
+	keys, err = repo.ListAPIKeys(context.Background())
+	if err != nil {
+		t.Fatalf("ListAPIKeys failed: %v", err)
+	}
+	var names []string
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	if len(names) != 3 || names[0] != "backup" || names[1] != "ci" || names[2] != "deploy" {
+		t.Errorf("Expected keys ordered by name, got %v", names)
+	}
+}
+
+func testDeleteAPIKey(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := createAPIKey(t, repo, "ci")
+
+	if err := repo.DeleteAPIKey(ctx, "ci"); err != nil {
+		t.Fatalf("DeleteAPIKey failed: %v", err)
+	}
+	if _, err := repo.GetAPIKeyByHash(ctx, key.Hash); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected a deleted key to be gone, got %v", err)
+	}
+	if err := repo.DeleteAPIKey(ctx, "ci"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
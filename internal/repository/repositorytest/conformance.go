@@ -0,0 +1,875 @@
+// Package repositorytest holds the behaviour every repository.BookRepository
+// implementation must share, so the service can rely on it whichever
+// backend is configured.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+// Factory returns an empty repository. It is called once per subtest.
+type Factory func(t *testing.T) repository.BookRepository
+
+// RunConformance runs the shared BookRepository suite against the
+// repositories returned by newRepository.
+func RunConformance(t *testing.T, newRepository Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.BookRepository)
+	}{
+		{"CreateBook", testCreateBook},
+		{"CreateBook/Conflict", testCreateBookConflict},
+		{"GetBookByID", testGetBookByID},
+		{"GetBookByISBN", testGetBookByISBN},
+		{"UpdateBook", testUpdateBook},
+		{"UpdateBook/Errors", testUpdateBookErrors},
+		{"DeleteBook", testDeleteBook},
+		{"UndeleteBook", testUndeleteBook},
+		{"PurgeBook", testPurgeBook},
+		{"PurgeDeletedBooks", testPurgeDeletedBooks},
+		{"PurgeDeletedBooks/Limit", testPurgeDeletedBooksLimit},
+		{"ListBooks", testListBooks},
+		{"ListBooks/Pagination", testListBooksPagination},
+		{"StreamBooks", testStreamBooks},
+		{"CountBooks", testCountBooks},
+		{"CreateBooks", testCreateBooks},
+		{"GetBooksByIDs", testGetBooksByIDs},
+		{"DeleteBooks", testDeleteBooks},
+		{"Revisions", testRevisions},
+		{"RestoreBookRevision", testRestoreBookRevision},
+		{"Concurrency/ConditionalUpdates", testConcurrentConditionalUpdates},
+		{"Concurrency/Creates", testConcurrentCreates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepository(t))
+		})
+	}
+}
+
+// ISBN returns a distinct canonical ISBN-13 for n < 1000, for tests that
+// need many valid books.
+func ISBN(n int) string {
+	body := fmt.Sprintf("978000000%03d", n)
+	sum := 0
+	for i, r := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	return fmt.Sprintf("%s%d", body, (10-sum%10)%10)
+}
+
+func newBook(n int) *domain.Book {
+	return &domain.Book{
+		Title:   fmt.Sprintf("Book %d", n),
+		Author:  fmt.Sprintf("Author %d", n%3),
+		Edition: 1,
+		ISBN:    ISBN(n),
+	}
+}
+
+func create(t *testing.T, repo repository.BookRepository, book *domain.Book) *domain.Book {
+	t.Helper()
+	created, err := repo.CreateBook(context.Background(), book)
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	return created
+}
+
+func get(t *testing.T, repo repository.BookRepository, id string) *domain.Book {
+	t.Helper()
+	book, err := repo.GetBookByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetBookByID(%s) failed: %v", id, err)
+	}
+	return book
+}
+
+func ids(books []*domain.Book) []string {
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return ids
+}
+
+func sortedIDs(books []*domain.Book) []string {
+	ids := ids(books)
+	slices.Sort(ids)
+	return ids
+}
+
+func expectError(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s: expected %v, got %v", what, want, err)
+	}
+}
+
+func testCreateBook(t *testing.T, repo repository.BookRepository) {
+	before := time.Now().Add(-time.Minute)
+	book := create(t, repo, newBook(1))
+
+	if book.ID == "" {
+		t.Error("Expected an ID to be assigned")
+	}
+	if book.Version != 1 {
+		t.Errorf("Expected version 1, got %d", book.Version)
+	}
+	if book.CreatedAt.Before(before) || !book.UpdatedAt.Equal(book.CreatedAt) {
+		t.Errorf("Expected fresh and equal timestamps, got created %v, updated %v", book.CreatedAt, book.UpdatedAt)
+	}
+	if book.DeletedAt != nil {
+		t.Errorf("Expected no DeletedAt, got %v", book.DeletedAt)
+	}
+
+	second := create(t, repo, newBook(2))
+	if second.ID == book.ID {
+		t.Errorf("Expected distinct IDs, both are %s", book.ID)
+	}
+}
+
+func testCreateBookConflict(t *testing.T, repo repository.BookRepository) {
+	existing := create(t, repo, newBook(1))
+
+	_, err := repo.CreateBook(context.Background(), newBook(1))
+	expectError(t, "duplicate CreateBook", err, repository.ErrAlreadyExists)
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingID != existing.ID {
+		t.Errorf("Expected a ConflictError naming %s, got %v", existing.ID, err)
+	}
+
+	// A deleted book keeps its ISBN and edition.
+	if err := repo.DeleteBook(context.Background(), existing.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	_, err = repo.CreateBook(context.Background(), newBook(1))
+	expectError(t, "CreateBook over a deleted book", err, repository.ErrAlreadyExists)
+
+	// Another edition of the same ISBN is a different book.
+	other := newBook(1)
+	other.Edition = 2
+	create(t, repo, other)
+}
+
+func testGetBookByID(t *testing.T, repo repository.BookRepository) {
+	created := create(t, repo, newBook(1))
+
+	book := get(t, repo, created.ID)
+	if book.Title != created.Title || book.Author != created.Author || book.Edition != created.Edition || book.ISBN != created.ISBN {
+		t.Errorf("Expected %+v, got %+v", created, book)
+	}
+	if book.Version != created.Version || !book.CreatedAt.Equal(created.CreatedAt) || !book.UpdatedAt.Equal(created.UpdatedAt) {
+		t.Errorf("Expected version and timestamps of %+v, got %+v", created, book)
+	}
+
+	_, err := repo.GetBookByID(context.Background(), "00000000-0000-0000-0000-000000000000")
+	expectError(t, "GetBookByID of a missing book", err, repository.ErrNotFound)
+}
+
+func testGetBookByISBN(t *testing.T, repo repository.BookRepository) {
+	for _, edition := range []int{3, 1, 2} {
+		book := newBook(1)
+		book.Edition = edition
+		create(t, repo, book)
+	}
+	create(t, repo, newBook(2))
+
+	books, err := repo.GetBookByISBN(context.Background(), ISBN(1))
+	if err != nil {
+		t.Fatalf("GetBookByISBN failed: %v", err)
+	}
+	if len(books) != 3 || books[0].Edition != 1 || books[1].Edition != 2 || books[2].Edition != 3 {
+		t.Errorf("Expected editions 1, 2 and 3 in order, got %+v", books)
+	}
+
+	_, err = repo.GetBookByISBN(context.Background(), ISBN(3))
+	expectError(t, "GetBookByISBN of an unknown ISBN", err, repository.ErrNotFound)
+}
+
+func testUpdateBook(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	created := create(t, repo, newBook(1))
+
+	changes := &domain.Book{ID: created.ID, Title: "New title", Author: "Ignored"}
+	updated, err := repo.UpdateBook(ctx, changes, []string{repository.FieldTitle}, created.Version)
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if updated.Title != "New title" || updated.Author != created.Author || updated.ISBN != created.ISBN {
+		t.Errorf("Expected only the title to change, got %+v", updated)
+	}
+	if updated.Version != created.Version+1 {
+		t.Errorf("Expected version %d, got %d", created.Version+1, updated.Version)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) || updated.UpdatedAt.Before(created.UpdatedAt) {
+		t.Errorf("Expected CreatedAt kept and UpdatedAt advanced, got %+v", updated)
+	}
+
+	if stored := get(t, repo, created.ID); stored.Title != "New title" || stored.Version != updated.Version {
+		t.Errorf("Expected the update to be stored, got %+v", stored)
+	}
+
+	// Without a version the update is unconditional.
+	unconditional, err := repo.UpdateBook(ctx, &domain.Book{ID: created.ID, Edition: 4, ISBN: ISBN(9)}, []string{repository.FieldEdition, repository.FieldISBN}, 0)
+	if err != nil {
+		t.Fatalf("unconditional UpdateBook failed: %v", err)
+	}
+	if unconditional.Edition != 4 || unconditional.ISBN != ISBN(9) || unconditional.Title != "New title" {
+		t.Errorf("Expected edition and ISBN to change, got %+v", unconditional)
+	}
+}
+
+func testUpdateBookErrors(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+	other := create(t, repo, newBook(2))
+
+	_, err := repo.UpdateBook(ctx, &domain.Book{ID: "00000000-0000-0000-0000-000000000000", Title: "T"}, []string{repository.FieldTitle}, 0)
+	expectError(t, "UpdateBook of a missing book", err, repository.ErrNotFound)
+
+	count, err := repo.CountBooks(ctx, repository.ListBooksParams{ShowDeleted: true})
+	if err != nil || count != 2 {
+		t.Errorf("Expected updating a missing book not to create one, have %d books (%v)", count, err)
+	}
+
+	_, err = repo.UpdateBook(ctx, &domain.Book{ID: book.ID, Title: "T"}, []string{repository.FieldTitle}, book.Version+1)
+	expectError(t, "UpdateBook with a stale version", err, repository.ErrVersionMismatch)
+
+	_, err = repo.UpdateBook(ctx, &domain.Book{ID: book.ID, ISBN: other.ISBN, Edition: other.Edition}, []string{repository.FieldISBN, repository.FieldEdition}, 0)
+	expectError(t, "UpdateBook onto another book's ISBN and edition", err, repository.ErrAlreadyExists)
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingID != other.ID {
+		t.Errorf("Expected a ConflictError naming %s, got %v", other.ID, err)
+	}
+
+	if _, err := repo.UpdateBook(ctx, &domain.Book{ID: book.ID}, []string{"id"}, 0); err == nil {
+		t.Error("Expected an error updating an immutable field")
+	}
+	if _, err := repo.UpdateBook(ctx, &domain.Book{ID: book.ID}, nil, 0); err == nil {
+		t.Error("Expected an error updating no fields")
+	}
+
+	if stored := get(t, repo, book.ID); stored.Version != book.Version || stored.Title != book.Title {
+		t.Errorf("Expected failed updates to leave the book untouched, got %+v", stored)
+	}
+
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	_, err = repo.UpdateBook(ctx, &domain.Book{ID: book.ID, Title: "T"}, []string{repository.FieldTitle}, 0)
+	expectError(t, "UpdateBook of a deleted book", err, repository.ErrNotFound)
+}
+
+func testDeleteBook(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+
+	expectError(t, "DeleteBook with a stale version", repo.DeleteBook(ctx, book.ID, book.Version+1), repository.ErrVersionMismatch)
+	expectError(t, "DeleteBook of a missing book", repo.DeleteBook(ctx, "00000000-0000-0000-0000-000000000000", 0), repository.ErrNotFound)
+
+	if err := repo.DeleteBook(ctx, book.ID, book.Version); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	deleted := get(t, repo, book.ID)
+	if deleted.DeletedAt == nil || deleted.Version != book.Version+1 {
+		t.Errorf("Expected a soft-deleted book at version %d, got %+v", book.Version+1, deleted)
+	}
+
+	expectError(t, "DeleteBook of a deleted book", repo.DeleteBook(ctx, book.ID, 0), repository.ErrNotFound)
+}
+
+func testUndeleteBook(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+
+	_, err := repo.UndeleteBook(ctx, book.ID, 0)
+	expectError(t, "UndeleteBook of a live book", err, repository.ErrNotDeleted)
+	_, err = repo.UndeleteBook(ctx, "00000000-0000-0000-0000-000000000000", 0)
+	expectError(t, "UndeleteBook of a missing book", err, repository.ErrNotFound)
+
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	_, err = repo.UndeleteBook(ctx, book.ID, book.Version)
+	expectError(t, "UndeleteBook with a stale version", err, repository.ErrVersionMismatch)
+
+	restored, err := repo.UndeleteBook(ctx, book.ID, book.Version+1)
+	if err != nil {
+		t.Fatalf("UndeleteBook failed: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Version != book.Version+2 || restored.Title != book.Title {
+		t.Errorf("Expected the live book at version %d, got %+v", book.Version+2, restored)
+	}
+	if stored := get(t, repo, book.ID); stored.DeletedAt != nil {
+		t.Errorf("Expected the undelete to be stored, got %+v", stored)
+	}
+}
+
+func testPurgeBook(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+
+	expectError(t, "PurgeBook of a live book", repo.PurgeBook(ctx, book.ID, 0), repository.ErrNotDeleted)
+	expectError(t, "PurgeBook of a missing book", repo.PurgeBook(ctx, "00000000-0000-0000-0000-000000000000", 0), repository.ErrNotFound)
+
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	expectError(t, "PurgeBook with a stale version", repo.PurgeBook(ctx, book.ID, book.Version), repository.ErrVersionMismatch)
+
+	if err := repo.PurgeBook(ctx, book.ID, book.Version+1); err != nil {
+		t.Fatalf("PurgeBook failed: %v", err)
+	}
+	_, err := repo.GetBookByID(ctx, book.ID)
+	expectError(t, "GetBookByID of a purged book", err, repository.ErrNotFound)
+
+	// The ISBN and edition are free again.
+	create(t, repo, newBook(1))
+}
+
+func testPurgeDeletedBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	older := create(t, repo, newBook(1))
+	newer := create(t, repo, newBook(2))
+	live := create(t, repo, newBook(3))
+
+	if err := repo.DeleteBook(ctx, older.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := repo.DeleteBook(ctx, newer.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+
+	purged, err := repo.PurgeDeletedBooks(ctx, *get(t, repo, newer.ID).DeletedAt, 10)
+	if err != nil {
+		t.Fatalf("PurgeDeletedBooks failed: %v", err)
+	}
+	if !slices.Equal(purged, []string{older.ID}) {
+		t.Errorf("Expected only %s to be purged, got %v", older.ID, purged)
+	}
+
+	get(t, repo, newer.ID)
+	get(t, repo, live.ID)
+	_, err = repo.GetBookByID(ctx, older.ID)
+	expectError(t, "GetBookByID of a purged book", err, repository.ErrNotFound)
+}
+
+func testPurgeDeletedBooksLimit(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		book := create(t, repo, newBook(i))
+		if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+			t.Fatalf("DeleteBook failed: %v", err)
+		}
+		ids = append(ids, book.ID)
+		time.Sleep(10 * time.Millisecond)
+	}
+	before := time.Now().Add(time.Hour)
+
+	// The longest deleted books go first, though not necessarily in order.
+	purged, err := repo.PurgeDeletedBooks(ctx, before, 2)
+	if err != nil {
+		t.Fatalf("PurgeDeletedBooks failed: %v", err)
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(purged)), slices.Sorted(slices.Values(ids[:2]))) {
+		t.Errorf("Expected %v to be purged, got %v", ids[:2], purged)
+	}
+	get(t, repo, ids[2])
+
+	if purged, err = repo.PurgeDeletedBooks(ctx, before, 2); err != nil || !slices.Equal(purged, ids[2:]) {
+		t.Errorf("Expected %v to be purged, got %v (%v)", ids[2:], purged, err)
+	}
+	if purged, err = repo.PurgeDeletedBooks(ctx, before, 2); err != nil || len(purged) != 0 {
+		t.Errorf("Expected nothing left to purge, got %v (%v)", purged, err)
+	}
+}
+
+// seed creates books 1 to n, editions all 1, authors cycling through three
+// values, and deletes the last one.
+func seed(t *testing.T, repo repository.BookRepository, n int) []*domain.Book {
+	t.Helper()
+	var books []*domain.Book
+	for i := 1; i <= n; i++ {
+		books = append(books, create(t, repo, newBook(i)))
+	}
+	if err := repo.DeleteBook(context.Background(), books[n-1].ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	return books
+}
+
+func parseFilter(t *testing.T, expr string) filter.Expr {
+	t.Helper()
+	parsed, err := filter.Parse(expr, repository.BookFilterSchema)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", expr, err)
+	}
+	return parsed
+}
+
+func parseOrderBy(t *testing.T, orderBy string) []filter.Order {
+	t.Helper()
+	parsed, err := filter.ParseOrderBy(orderBy, repository.BookFilterSchema)
+	if err != nil {
+		t.Fatalf("ParseOrderBy(%q) failed: %v", orderBy, err)
+	}
+	return parsed
+}
+
+func testListBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	books := seed(t, repo, 7)
+
+	all, err := repo.ListBooks(ctx, repository.ListBooksParams{})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if !slices.Equal(ids(all), sortedIDs(books[:6])) {
+		t.Errorf("Expected the live books ordered by ID, got %v", ids(all))
+	}
+
+	withDeleted, err := repo.ListBooks(ctx, repository.ListBooksParams{ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if len(withDeleted) != 7 {
+		t.Errorf("Expected 7 books with ShowDeleted, got %d", len(withDeleted))
+	}
+
+	filtered, err := repo.ListBooks(ctx, repository.ListBooksParams{
+		Filter:  parseFilter(t, `author = "Author 1" OR title = "Book 3"`),
+		OrderBy: parseOrderBy(t, "title desc"),
+	})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	var titles []string
+	for _, book := range filtered {
+		titles = append(titles, book.Title)
+	}
+	if want := []string{"Book 4", "Book 3", "Book 1"}; !slices.Equal(titles, want) {
+		t.Errorf("Expected %v, got %v", want, titles)
+	}
+
+	limited, err := repo.ListBooks(ctx, repository.ListBooksParams{PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("Expected 2 books, got %d", len(limited))
+	}
+}
+
+func testListBooksPagination(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	books := seed(t, repo, 10)
+	orderBy := parseOrderBy(t, "author desc, title")
+
+	var (
+		seen   []*domain.Book
+		cursor *repository.Cursor
+	)
+	for page := 0; page < 10; page++ {
+		batch, err := repo.ListBooks(ctx, repository.ListBooksParams{OrderBy: orderBy, PageSize: 4, After: cursor})
+		if err != nil {
+			t.Fatalf("ListBooks failed: %v", err)
+		}
+		seen = append(seen, batch...)
+		if len(batch) < 4 {
+			break
+		}
+		last := batch[len(batch)-1]
+		cursor = &repository.Cursor{ID: last.ID}
+		for _, order := range orderBy {
+			cursor.Values = append(cursor.Values, repository.BookFieldValue(last, order.Field))
+		}
+	}
+
+	if !slices.Equal(sortedIDs(seen), sortedIDs(books[:9])) {
+		t.Fatalf("Expected every live book exactly once, got %v", ids(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		a, b := seen[i-1], seen[i]
+		if a.Author < b.Author || (a.Author == b.Author && a.Title > b.Title) {
+			t.Errorf("Books %q and %q are out of order", a.Title, b.Title)
+		}
+	}
+}
+
+func testStreamBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	seed(t, repo, 5)
+	params := repository.ListBooksParams{Filter: parseFilter(t, "edition = 1"), OrderBy: parseOrderBy(t, "title")}
+
+	listed, err := repo.ListBooks(ctx, params)
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	var streamed []*domain.Book
+	for book, err := range repo.StreamBooks(ctx, params) {
+		if err != nil {
+			t.Fatalf("StreamBooks failed: %v", err)
+		}
+		streamed = append(streamed, book)
+	}
+	if !slices.Equal(ids(streamed), ids(listed)) {
+		t.Errorf("Expected StreamBooks to match ListBooks %v, got %v", ids(listed), ids(streamed))
+	}
+
+	// Stopping early must be safe.
+	count := 0
+	for _, err := range repo.StreamBooks(ctx, params) {
+		if err != nil {
+			t.Fatalf("StreamBooks failed: %v", err)
+		}
+		count++
+		if count == 2 {
+			break
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	var streamErr error
+	for _, err := range repo.StreamBooks(cancelled, params) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+	}
+	if streamErr == nil {
+		t.Error("Expected an error streaming with a cancelled context")
+	}
+}
+
+func testCountBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	seed(t, repo, 6)
+
+	tests := []struct {
+		params repository.ListBooksParams
+		want   int
+	}{
+		{repository.ListBooksParams{}, 5},
+		{repository.ListBooksParams{ShowDeleted: true}, 6},
+		{repository.ListBooksParams{Filter: parseFilter(t, `author = "Author 0"`)}, 1},
+		{repository.ListBooksParams{Filter: parseFilter(t, `author = "Author 0"`), ShowDeleted: true}, 2},
+		// Paging fields are ignored.
+		{repository.ListBooksParams{PageSize: 1}, 5},
+	}
+	for _, tt := range tests {
+		count, err := repo.CountBooks(ctx, tt.params)
+		if err != nil {
+			t.Fatalf("CountBooks failed: %v", err)
+		}
+		if count != tt.want {
+			t.Errorf("CountBooks(%+v) = %d, want %d", tt.params, count, tt.want)
+		}
+	}
+}
+
+func testCreateBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	existing := create(t, repo, newBook(1))
+
+	_, err := repo.CreateBooks(ctx, []*domain.Book{newBook(2), newBook(1)})
+	expectError(t, "CreateBooks with an existing book", err, repository.ErrAlreadyExists)
+	_, err = repo.CreateBooks(ctx, []*domain.Book{newBook(3), newBook(3)})
+	expectError(t, "CreateBooks with a repeated book", err, repository.ErrAlreadyExists)
+	if count, _ := repo.CountBooks(ctx, repository.ListBooksParams{ShowDeleted: true}); count != 1 {
+		t.Fatalf("Expected failed batches to create nothing, have %d books", count)
+	}
+
+	created, err := repo.CreateBooks(ctx, []*domain.Book{newBook(2), newBook(3)})
+	if err != nil {
+		t.Fatalf("CreateBooks failed: %v", err)
+	}
+	if len(created) != 2 || created[0].Title != "Book 2" || created[1].Title != "Book 3" {
+		t.Fatalf("Expected books 2 and 3 in request order, got %+v", created)
+	}
+	for _, book := range created {
+		if book.ID == "" || book.ID == existing.ID || book.Version != 1 || book.CreatedAt.IsZero() {
+			t.Errorf("Expected a new ID, version 1 and timestamps, got %+v", book)
+		}
+		if stored := get(t, repo, book.ID); stored.ISBN != book.ISBN {
+			t.Errorf("Expected %+v to be stored, got %+v", book, stored)
+		}
+	}
+
+	if empty, err := repo.CreateBooks(ctx, nil); err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty batch to succeed with no books, got %v, %v", empty, err)
+	}
+}
+
+func testGetBooksByIDs(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	books := seed(t, repo, 3)
+
+	found, err := repo.GetBooksByIDs(ctx, []string{books[0].ID, "00000000-0000-0000-0000-000000000000", books[2].ID, books[0].ID})
+	if err != nil {
+		t.Fatalf("GetBooksByIDs failed: %v", err)
+	}
+	if want := sortedIDs([]*domain.Book{books[0], books[2]}); !slices.Equal(sortedIDs(found), want) {
+		t.Errorf("Expected %v including the deleted book, got %v", want, ids(found))
+	}
+}
+
+func testDeleteBooks(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	books := seed(t, repo, 4)
+	missing := "00000000-0000-0000-0000-000000000000"
+
+	_, err := repo.DeleteBooks(ctx, []string{books[0].ID, missing}, false)
+	expectError(t, "all-or-nothing DeleteBooks with a missing book", err, repository.ErrNotFound)
+	_, err = repo.DeleteBooks(ctx, []string{books[0].ID, books[3].ID}, false)
+	expectError(t, "all-or-nothing DeleteBooks with a deleted book", err, repository.ErrNotFound)
+	if get(t, repo, books[0].ID).DeletedAt != nil {
+		t.Fatal("Expected failed all-or-nothing deletes to delete nothing")
+	}
+
+	deleted, err := repo.DeleteBooks(ctx, []string{books[0].ID, missing, books[1].ID, books[0].ID}, true)
+	if err != nil {
+		t.Fatalf("DeleteBooks failed: %v", err)
+	}
+	slices.Sort(deleted)
+	if want := sortedIDs(books[:2]); !slices.Equal(deleted, want) {
+		t.Errorf("Expected %v to be deleted, got %v", want, deleted)
+	}
+	for _, book := range books[:2] {
+		if stored := get(t, repo, book.ID); stored.DeletedAt == nil || stored.Version != 2 {
+			t.Errorf("Expected a soft-deleted book at version 2, got %+v", stored)
+		}
+	}
+
+	deleted, err = repo.DeleteBooks(ctx, []string{books[2].ID}, false)
+	if err != nil || !slices.Equal(deleted, []string{books[2].ID}) {
+		t.Errorf("Expected %s to be deleted, got %v, %v", books[2].ID, deleted, err)
+	}
+}
+
+func testRevisions(t *testing.T, repo repository.BookRepository) {
+	ctx := repository.WithActor(context.Background(), "alice")
+	book, err := repo.CreateBook(ctx, newBook(1))
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	bob := repository.WithActor(context.Background(), "bob")
+	if _, err := repo.UpdateBook(bob, &domain.Book{ID: book.ID, Title: "Renamed"}, []string{repository.FieldTitle}, 0); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	if _, err := repo.UndeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("UndeleteBook failed: %v", err)
+	}
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	if err := repo.PurgeBook(context.Background(), book.ID, 0); err != nil {
+		t.Fatalf("PurgeBook failed: %v", err)
+	}
+
+	revisions, err := repo.ListBookRevisions(ctx, book.ID, 0, 100)
+	if err != nil {
+		t.Fatalf("ListBookRevisions failed: %v", err)
+	}
+	want := []struct {
+		action domain.RevisionAction
+		actor  string
+		title  string
+	}{
+		{domain.RevisionCreate, "alice", "Book 1"},
+		{domain.RevisionUpdate, "bob", "Renamed"},
+		{domain.RevisionDelete, "alice", "Renamed"},
+		{domain.RevisionUndelete, "alice", "Renamed"},
+		{domain.RevisionDelete, "alice", "Renamed"},
+		{domain.RevisionPurge, repository.SystemActor, "Renamed"},
+	}
+	if len(revisions) != len(want) {
+		t.Fatalf("Expected %d revisions, got %d", len(want), len(revisions))
+	}
+	for i, rev := range revisions {
+		if rev.Revision != int64(i+1) || rev.Action != want[i].action || rev.Actor != want[i].actor || rev.Title != want[i].title || rev.BookID != book.ID {
+			t.Errorf("Revision %d: expected %d %s by %s titled %q, got %+v", i, i+1, want[i].action, want[i].actor, want[i].title, rev)
+		}
+		if rev.CreatedAt.IsZero() {
+			t.Errorf("Revision %d has no CreatedAt", i)
+		}
+	}
+
+	window, err := repo.ListBookRevisions(ctx, book.ID, 3, 2)
+	if err != nil {
+		t.Fatalf("ListBookRevisions failed: %v", err)
+	}
+	if len(window) != 2 || window[0].Revision != 3 || window[1].Revision != 4 {
+		t.Errorf("Expected revisions 3 and 4, got %+v", window)
+	}
+
+	none, err := repo.ListBookRevisions(ctx, "00000000-0000-0000-0000-000000000000", 0, 10)
+	if err != nil || len(none) != 0 {
+		t.Errorf("Expected no revisions for an unknown book, got %v, %v", none, err)
+	}
+}
+
+func testRestoreBookRevision(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+	other := create(t, repo, newBook(2))
+
+	fields := []string{repository.FieldTitle, repository.FieldISBN}
+	if _, err := repo.UpdateBook(ctx, &domain.Book{ID: book.ID, Title: "Wrong", ISBN: ISBN(5)}, fields, 0); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	_, err := repo.RestoreBookRevision(ctx, book.ID, 1, 1)
+	expectError(t, "RestoreBookRevision with a stale version", err, repository.ErrVersionMismatch)
+	_, err = repo.RestoreBookRevision(ctx, book.ID, 9, 0)
+	expectError(t, "RestoreBookRevision of an unknown revision", err, repository.ErrNotFound)
+
+	restored, err := repo.RestoreBookRevision(ctx, book.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("RestoreBookRevision failed: %v", err)
+	}
+	if restored.Title != book.Title || restored.ISBN != book.ISBN || restored.Version != 3 {
+		t.Errorf("Expected revision 1 back at version 3, got %+v", restored)
+	}
+	revisions, err := repo.ListBookRevisions(ctx, book.ID, 3, 1)
+	if err != nil || len(revisions) != 1 || revisions[0].Action != domain.RevisionRestore {
+		t.Errorf("Expected a restore revision, got %v, %v", revisions, err)
+	}
+
+	// Restoring onto values another book now holds is a conflict.
+	if _, err := repo.UpdateBook(ctx, &domain.Book{ID: other.ID, ISBN: ISBN(5)}, []string{repository.FieldISBN}, 0); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	_, err = repo.RestoreBookRevision(ctx, book.ID, 2, 0)
+	expectError(t, "RestoreBookRevision onto another book's ISBN", err, repository.ErrAlreadyExists)
+
+	if err := repo.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	_, err = repo.RestoreBookRevision(ctx, book.ID, 1, 0)
+	expectError(t, "RestoreBookRevision of a deleted book", err, repository.ErrNotFound)
+}
+
+// persist repeats write while it fails with repository.ErrRetriesExhausted,
+// which a backend may report when a writer keeps losing conflicts, so that
+// the concurrency tests below can still demand exactly one winner. Each
+// attempt waits a little longer to let the contention die down.
+func persist(write func() error) error {
+	const attempts = 10
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = write(); !errors.Is(err, repository.ErrRetriesExhausted) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+	return err
+}
+
+// testConcurrentConditionalUpdates races writers holding the same version:
+// exactly one must win, the rest must see a version mismatch.
+func testConcurrentConditionalUpdates(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+	book := create(t, repo, newBook(1))
+
+	const writers = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			changes := &domain.Book{ID: book.ID, Title: fmt.Sprintf("Writer %d", i)}
+			err := persist(func() error {
+				_, err := repo.UpdateBook(ctx, changes, []string{repository.FieldTitle}, book.Version)
+				return err
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, repository.ErrVersionMismatch):
+				t.Errorf("UpdateBook failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one conditional update to win, %d did", succeeded)
+	}
+	if stored := get(t, repo, book.ID); stored.Version != book.Version+1 {
+		t.Errorf("Expected version %d, got %d", book.Version+1, stored.Version)
+	}
+}
+
+func testConcurrentCreates(t *testing.T, repo repository.BookRepository) {
+	ctx := context.Background()
+
+	const writers = 8
+	var wg sync.WaitGroup
+	distinct := make([]error, writers)
+	shared := make([]error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every writer adds a distinct book and races on a shared one.
+			distinct[i] = persist(func() error {
+				_, err := repo.CreateBook(ctx, newBook(10+i))
+				return err
+			})
+			shared[i] = persist(func() error {
+				_, err := repo.CreateBook(ctx, newBook(1))
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for i := 0; i < writers; i++ {
+		if err := distinct[i]; err != nil {
+			t.Errorf("CreateBook of a distinct book failed: %v", err)
+		}
+		switch err := shared[i]; {
+		case err == nil:
+			created++
+		case !errors.Is(err, repository.ErrAlreadyExists):
+			t.Errorf("CreateBook of the shared book failed: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one writer to create the shared book, %d did", created)
+	}
+	if count, _ := repo.CountBooks(ctx, repository.ListBooksParams{}); count != writers+1 {
+		t.Errorf("Expected %d books, got %d", writers+1, count)
+	}
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+const selectAPIKeys = `SELECT id, name, key_hash, created_at FROM api_keys`
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) repository.APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	id, at := uuid.NewString(), now()
+	stmt := `INSERT INTO api_keys (id, name, key_hash, created_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.db.ExecContext(ctx, stmt, id, key.Name, key.Hash, at); err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	key.ID, key.CreatedAt = id, at
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	err := r.db.QueryRowContext(ctx, selectAPIKeys+` WHERE key_hash = $1`, hash).Scan(&key.ID, &key.Name, &key.Hash, &key.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, selectAPIKeys+` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key := &domain.APIKey{}
+		if err := rows.Scan(&key.ID, &key.Name, &key.Hash, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) DeleteAPIKey(ctx context.Context, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlquery"
+)
+
+func (r *BookRepository) CreateBooks(ctx context.Context, books []*domain.Book) ([]*domain.Book, error) {
+	if len(books) == 0 {
+		return books, nil
+	}
+
+	// IDs are assigned here, so unlike CockroachDB no RETURNING is needed to
+	// match rows back to their books.
+	at := now()
+	ids := make([]string, len(books))
+	b := &sqlquery.Builder{}
+	values := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = uuid.NewString()
+		values[i] = fmt.Sprintf("(%s, %s, %s, %s, %s, 1, %s, %s)",
+			b.Arg(ids[i]), b.Arg(book.Title), b.Arg(book.Author), b.Arg(book.Edition), b.Arg(book.ISBN), b.Arg(at), b.Arg(at))
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO books (id, title, author, edition, isbn, version, created_at, updated_at) VALUES ` + strings.Join(values, ", ")
+	if _, err := tx.ExecContext(ctx, stmt, b.Args...); err != nil {
+		return nil, batchError(err)
+	}
+
+	for i, book := range books {
+		book.ID = ids[i]
+		book.Version = 1
+		book.CreatedAt, book.UpdatedAt = at, at
+	}
+
+	if err := recordRevisions(ctx, tx, domain.RevisionCreate, books...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+func (r *BookRepository) GetBooksByIDs(ctx context.Context, ids []string) ([]*domain.Book, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	b := &sqlquery.Builder{}
+	rows, err := r.db.QueryContext(ctx, selectBooks+` WHERE id IN (`+b.List(ids)+`)`, b.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBooks(rows)
+}
+
+func (r *BookRepository) DeleteBooks(ctx context.Context, ids []string, partial bool) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	b := &sqlquery.Builder{}
+	at := b.Arg(now())
+	stmt := `UPDATE books SET deleted_at = ` + at + `, version = version + 1, updated_at = ` + at +
+		` WHERE id IN (` + b.List(ids) + `) AND deleted_at IS NULL RETURNING ` + bookProjection
+	rows, err := tx.QueryContext(ctx, stmt, b.Args...)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if !partial && len(books) != len(uniqueIDs(ids)) {
+		return nil, repository.ErrNotFound
+	}
+
+	if err := recordRevisions(ctx, tx, domain.RevisionDelete, books...); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]string, len(books))
+	for i, book := range books {
+		deleted[i] = book.ID
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// batchError maps a unique violation in a multi-row write to
+// ErrAlreadyExists. Unlike single-row writes, the conflicting row cannot be
+// attributed to one book of the batch.
+func batchError(err error) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", repository.ErrAlreadyExists, err)
+	}
+	return err
+}
+
+func uniqueIDs(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,420 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/sqlquery"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// bookProjection lists the columns scanned by scanBook.
+const bookProjection = `id, title, author, edition, isbn, version, created_at, updated_at, deleted_at`
+
+// selectBooks is the common projection scanned by scanBooks.
+const selectBooks = `SELECT ` + bookProjection + ` FROM books`
+
+// BookRepository stores books in SQLite. IDs and timestamps are generated
+// here rather than by the database, which has no UUID or timestamp types;
+// timestamps are stored as UTC text, which sorts chronologically.
+type BookRepository struct {
+	repository.BookRepository
+
+	db *sql.DB
+}
+
+func NewBookRepository(db *sql.DB) repository.BookRepository {
+	return &BookRepository{
+		db: db,
+	}
+}
+
+// now returns the current time at the microsecond precision CockroachDB
+// stores, so both backends round-trip the same values.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func (r *BookRepository) CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, at := uuid.NewString(), now()
+	stmt := `INSERT INTO books (id, title, author, edition, isbn, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, 1, $6, $6)`
+	if _, err := tx.ExecContext(ctx, stmt, id, book.Title, book.Author, book.Edition, book.ISBN, at); err != nil {
+		return nil, translateUniqueViolation(ctx, tx, err, book)
+	}
+	book.ID, book.Version, book.CreatedAt, book.UpdatedAt = id, 1, at, at
+
+	if err := recordRevisions(ctx, tx, domain.RevisionCreate, book); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+func (r *BookRepository) GetBookByID(ctx context.Context, id string) (*domain.Book, error) {
+	row := r.db.QueryRowContext(ctx, selectBooks+` WHERE id = $1`, id)
+	book := &domain.Book{}
+	if err := scanBook(row, book); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *BookRepository) GetBookByISBN(ctx context.Context, isbn string) ([]*domain.Book, error) {
+	// Served by books_isbn_edition_key, whose leading column is isbn.
+	rows, err := r.db.QueryContext(ctx, selectBooks+` WHERE isbn = $1 ORDER BY edition`, isbn)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return books, nil
+}
+
+func (r *BookRepository) UpdateBook(ctx context.Context, book *domain.Book, fields []string, version int64) (*domain.Book, error) {
+	b := &sqlquery.Builder{}
+	var assignments []string
+	for _, field := range fields {
+		var value any
+		switch field {
+		case repository.FieldTitle:
+			value = book.Title
+		case repository.FieldAuthor:
+			value = book.Author
+		case repository.FieldEdition:
+			value = book.Edition
+		case repository.FieldISBN:
+			value = book.ISBN
+		default:
+			return nil, fmt.Errorf("field %q cannot be updated", field)
+		}
+		assignments = append(assignments, sqlquery.BookColumns[field]+" = "+b.Arg(value))
+	}
+	if len(assignments) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt := `UPDATE books SET ` + strings.Join(assignments, ", ") + `, version = version + 1, updated_at = ` + b.Arg(now()) +
+		` WHERE deleted_at IS NULL AND id = ` + b.Arg(book.ID)
+	if version != 0 {
+		stmt += ` AND version = ` + b.Arg(version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	updated := &domain.Book{}
+	if err := scanBook(tx.QueryRowContext(ctx, stmt, b.Args...), updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, missingOrConflict(ctx, tx, book.ID, false)
+		}
+		return nil, translateUniqueViolation(ctx, tx, err, book)
+	}
+
+	if err := recordRevisions(ctx, tx, domain.RevisionUpdate, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (r *BookRepository) DeleteBook(ctx context.Context, id string, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	at := now()
+	stmt := `UPDATE books SET deleted_at = $1, version = version + 1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+	args := []any{at, id}
+	if version != 0 {
+		stmt += ` AND version = $3`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	book := &domain.Book{}
+	if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+		if err == sql.ErrNoRows {
+			return missingOrConflict(ctx, tx, id, false)
+		}
+		return err
+	}
+
+	if err := recordRevisions(ctx, tx, domain.RevisionDelete, book); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookRepository) UndeleteBook(ctx context.Context, id string, version int64) (*domain.Book, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The unique index on (isbn, edition) covers deleted books as well, so
+	// restoring one can never collide with another book.
+	stmt := `UPDATE books SET deleted_at = NULL, version = version + 1, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`
+	args := []any{now(), id}
+	if version != 0 {
+		stmt += ` AND version = $3`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	book := &domain.Book{}
+	if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, missingOrConflict(ctx, tx, id, true)
+		}
+		return nil, err
+	}
+
+	if err := recordRevisions(ctx, tx, domain.RevisionUndelete, book); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (r *BookRepository) PurgeBook(ctx context.Context, id string, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `DELETE FROM books WHERE id = $1 AND deleted_at IS NOT NULL`
+	args := []any{id}
+	if version != 0 {
+		stmt += ` AND version = $2`
+		args = append(args, version)
+	}
+	stmt += ` RETURNING ` + bookProjection
+
+	book := &domain.Book{}
+	if err := scanBook(tx.QueryRowContext(ctx, stmt, args...), book); err != nil {
+		if err == sql.ErrNoRows {
+			return missingOrConflict(ctx, tx, id, true)
+		}
+		return err
+	}
+
+	if err := recordPurges(ctx, tx, book); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BookRepository) PurgeDeletedBooks(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Served by books_deleted_at_idx. SQLite only takes a LIMIT on DELETE when
+	// built with SQLITE_ENABLE_UPDATE_DELETE_LIMIT, hence the subquery.
+	stmt := `DELETE FROM books WHERE id IN (SELECT id FROM books WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2) RETURNING ` + bookProjection
+	rows, err := tx.QueryContext(ctx, stmt, before.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	books, err := scanBooks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordPurges(ctx, tx, books...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return ids, nil
+}
+
+// recordPurges records the removal of books. The row is gone, so the purge
+// takes the revision number after the book's last version.
+func recordPurges(ctx context.Context, tx *sql.Tx, books ...*domain.Book) error {
+	for _, book := range books {
+		book.Version++
+	}
+	return recordRevisions(ctx, tx, domain.RevisionPurge, books...)
+}
+
+// missingOrConflict explains why a write on id touched no rows. deleted is
+// the state the write required the book to be in, as in the cockroach
+// implementation.
+func missingOrConflict(ctx context.Context, tx *sql.Tx, id string, deleted bool) error {
+	var isDeleted bool
+	err := tx.QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM books WHERE id = $1`, id).Scan(&isDeleted)
+	switch {
+	case err == sql.ErrNoRows:
+		return repository.ErrNotFound
+	case err != nil:
+		return err
+	case isDeleted == deleted:
+		return repository.ErrVersionMismatch
+	case deleted:
+		return repository.ErrNotDeleted
+	}
+	return repository.ErrNotFound
+}
+
+// isUniqueViolation reports whether err is a unique index rejecting a write.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// translateUniqueViolation turns a unique violation on (isbn, edition) into
+// a repository.ConflictError naming the book that already holds the pair.
+// Unlike CockroachDB, SQLite keeps the transaction usable after the failed
+// statement, so the owner is looked up inside it.
+func translateUniqueViolation(ctx context.Context, tx *sql.Tx, err error, book *domain.Book) error {
+	if !isUniqueViolation(err) {
+		return err
+	}
+
+	var existingID string
+	lookup := `SELECT id FROM books WHERE isbn = $1 AND edition = $2`
+	if err := tx.QueryRowContext(ctx, lookup, book.ISBN, book.Edition).Scan(&existingID); err != nil {
+		return repository.ErrAlreadyExists
+	}
+	return &repository.ConflictError{ExistingID: existingID}
+}
+
+func (r *BookRepository) ListBooks(ctx context.Context, params repository.ListBooksParams) ([]*domain.Book, error) {
+	stmt, args, err := sqlquery.ListStatement(selectBooks, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanBooks(rows)
+}
+
+// StreamBooks yields books as they are read from the result set. Cancelling
+// ctx aborts the query.
+func (r *BookRepository) StreamBooks(ctx context.Context, params repository.ListBooksParams) iter.Seq2[*domain.Book, error] {
+	return func(yield func(*domain.Book, error) bool) {
+		stmt, args, err := sqlquery.ListStatement(selectBooks, params)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		rows, err := r.db.QueryContext(ctx, stmt, args...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			book := &domain.Book{}
+			if err := scanBook(rows, book); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(book, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func (r *BookRepository) CountBooks(ctx context.Context, params repository.ListBooksParams) (int, error) {
+	stmt, args, err := sqlquery.CountStatement(params)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, stmt, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// scanBooks reads every row of a selectBooks query and closes rows.
+func scanBooks(rows *sql.Rows) ([]*domain.Book, error) {
+	defer rows.Close()
+	var books []*domain.Book
+	for rows.Next() {
+		var book domain.Book
+		if err := scanBook(rows, &book); err != nil {
+			return nil, err
+		}
+		books = append(books, &book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// scanBook reads the selectBooks projection from a single row.
+func scanBook(row interface{ Scan(dest ...any) error }, book *domain.Book) error {
+	return row.Scan(&book.ID, &book.Title, &book.Author, &book.Edition, &book.ISBN, &book.Version, &book.CreatedAt, &book.UpdatedAt, &book.DeletedAt)
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/igoventura/go-grpc-library-service/internal/migrate"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/repositorytest"
+	"github.com/igoventura/go-grpc-library-service/migrations"
+)
+
+// TestConformance runs the shared suite against a fresh database file per
+// test, migrated with the embedded SQLite migrations.
+func TestConformance(t *testing.T) {
+	repositorytest.RunConformance(t, func(t *testing.T) repository.BookRepository {
+		return NewBookRepository(openScratchDatabase(t))
+	})
+}
+
+func TestAPIKeyConformance(t *testing.T) {
+	repositorytest.RunAPIKeyConformance(t, func(t *testing.T) repository.APIKeyRepository {
+		return NewAPIKeyRepository(openScratchDatabase(t))
+	})
+}
+
+func openScratchDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.SQLite, migrations.SQLite())
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database file at path, creating it if needed. The
+// schema is not created here; apply migrations.SQLite with internal/migrate
+// first.
+//
+// SQLite allows a single writer at a time. Write-ahead logging keeps
+// readers from blocking it, every transaction takes the write lock up front
+// so two of them can never deadlock upgrading their locks, and the busy
+// timeout makes writers queue instead of failing with SQLITE_BUSY.
+func Open(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Set("_txlock", "immediate")
+	query.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,213 @@
+// Package sqlquery renders the SQL shared by the database-backed
+// repositories: filters, keyset pagination and ordering over the books
+// table. Placeholders are numbered ($1, $2, ...), which both CockroachDB
+// and SQLite accept.
+package sqlquery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+// BookColumns maps repository fields to columns of the books table.
+var BookColumns = map[string]string{
+	repository.FieldTitle:      "title",
+	repository.FieldAuthor:     "author",
+	repository.FieldEdition:    "edition",
+	repository.FieldISBN:       "isbn",
+	repository.FieldCreateTime: "created_at",
+	repository.FieldUpdateTime: "updated_at",
+}
+
+// Builder accumulates positional arguments while SQL fragments are
+// rendered, so every literal ends up as a bind parameter.
+type Builder struct {
+	Args []any
+}
+
+// Arg adds a bind parameter and returns its placeholder. Times are bound in
+// UTC so that backends storing them as text still compare them correctly.
+func (b *Builder) Arg(v any) string {
+	if t, ok := v.(time.Time); ok {
+		v = t.UTC()
+	}
+	b.Args = append(b.Args, v)
+	return fmt.Sprintf("$%d", len(b.Args))
+}
+
+// List adds one bind parameter per value and returns their placeholders
+// separated by commas, for use in an IN list.
+func (b *Builder) List(values []string) string {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.Arg(v)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+func (b *Builder) column(field string) (string, error) {
+	column, ok := BookColumns[field]
+	if !ok {
+		return "", fmt.Errorf("unknown field %q", field)
+	}
+	return column, nil
+}
+
+// Matching renders the conditions selecting the books params asks for,
+// ignoring its paging fields.
+func (b *Builder) Matching(params repository.ListBooksParams) ([]string, error) {
+	var conditions []string
+	if !params.ShowDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if params.Filter != nil {
+		where, err := b.Where(params.Filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, where)
+	}
+	return conditions, nil
+}
+
+// Where renders a filter expression as a SQL condition.
+func (b *Builder) Where(expr filter.Expr) (string, error) {
+	switch e := expr.(type) {
+	case filter.And:
+		return b.binary(e.Left, "AND", e.Right)
+	case filter.Or:
+		return b.binary(e.Left, "OR", e.Right)
+	case filter.Not:
+		inner, err := b.Where(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	case filter.Comparison:
+		column, err := b.column(e.Field)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", column, sqlOperator(e.Op), b.Arg(e.Value)), nil
+	}
+	return "", fmt.Errorf("unsupported filter expression %T", expr)
+}
+
+func (b *Builder) binary(left filter.Expr, op string, right filter.Expr) (string, error) {
+	l, err := b.Where(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := b.Where(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// After renders the keyset predicate selecting rows that sort strictly after
+// cursor under orders, with id as the final ascending tie-breaker:
+//
+//	(a > $1) OR (a = $1 AND b < $2) OR (a = $1 AND b = $2 AND id > $3)
+func (b *Builder) After(orders []filter.Order, cursor *repository.Cursor) (string, error) {
+	if len(cursor.Values) != len(orders) {
+		return "", fmt.Errorf("cursor has %d values for %d order fields", len(cursor.Values), len(orders))
+	}
+
+	var (
+		terms  []string
+		prefix []string
+	)
+	for i, order := range orders {
+		column, err := b.column(order.Field)
+		if err != nil {
+			return "", err
+		}
+		value := b.Arg(cursor.Values[i])
+		op := ">"
+		if order.Desc {
+			op = "<"
+		}
+		terms = append(terms, "("+strings.Join(append(prefix, column+" "+op+" "+value), " AND ")+")")
+		prefix = append(prefix, column+" = "+value)
+	}
+	terms = append(terms, "("+strings.Join(append(prefix, "id > "+b.Arg(cursor.ID)), " AND ")+")")
+
+	return "(" + strings.Join(terms, " OR ") + ")", nil
+}
+
+// OrderBy renders the ORDER BY terms for orders, ending with id.
+func (b *Builder) OrderBy(orders []filter.Order) (string, error) {
+	var terms []string
+	for _, order := range orders {
+		column, err := b.column(order.Field)
+		if err != nil {
+			return "", err
+		}
+		if order.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	return strings.Join(append(terms, "id"), ", "), nil
+}
+
+func sqlOperator(op filter.Operator) string {
+	if op == filter.NotEqual {
+		return "<>"
+	}
+	return string(op)
+}
+
+// ListStatement renders the SELECT for a ListBooks or StreamBooks call.
+// selectBooks is the backend's "SELECT ... FROM books" prefix.
+func ListStatement(selectBooks string, params repository.ListBooksParams) (string, []any, error) {
+	b := &Builder{}
+	conditions, err := b.Matching(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if params.After != nil {
+		after, err := b.After(params.OrderBy, params.After)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, after)
+	}
+
+	orderBy, err := b.OrderBy(params.OrderBy)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stmt := selectBooks
+	if len(conditions) > 0 {
+		stmt += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	stmt += ` ORDER BY ` + orderBy
+	if params.PageSize > 0 {
+		stmt += ` LIMIT ` + b.Arg(params.PageSize)
+	}
+
+	return stmt, b.Args, nil
+}
+
+// CountStatement renders the SELECT count(*) for a CountBooks call.
+func CountStatement(params repository.ListBooksParams) (string, []any, error) {
+	b := &Builder{}
+	conditions, err := b.Matching(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stmt := `SELECT count(*) FROM books`
+	if len(conditions) > 0 {
+		stmt += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	return stmt, b.Args, nil
+}
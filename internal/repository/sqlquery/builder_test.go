@@ -0,0 +1,79 @@
+package sqlquery
+
+import (
+	"testing"
+
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+)
+
+func TestQueryBuilder_Where(t *testing.T) {
+	expr, err := filter.Parse(`author = "Donovan" AND (edition > 1 OR NOT title != "Go")`, repository.BookFilterSchema)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	b := &Builder{}
+	where, err := b.Where(expr)
+	if err != nil {
+		t.Fatalf("Where failed: %v", err)
+	}
+
+	want := `(author = $1 AND (edition > $2 OR NOT (title <> $3)))`
+	if where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if len(b.Args) != 3 || b.Args[0] != "Donovan" || b.Args[1] != int64(1) || b.Args[2] != "Go" {
+		t.Errorf("unexpected args %v", b.Args)
+	}
+}
+
+func TestQueryBuilder_After(t *testing.T) {
+	orders := []filter.Order{{Field: repository.FieldAuthor}, {Field: repository.FieldEdition, Desc: true}}
+	cursor := &repository.Cursor{Values: []any{"Donovan", int64(2)}, ID: "some-id"}
+
+	b := &Builder{}
+	after, err := b.After(orders, cursor)
+	if err != nil {
+		t.Fatalf("After failed: %v", err)
+	}
+
+	want := `((author > $1) OR (author = $1 AND edition < $2) OR (author = $1 AND edition = $2 AND id > $3))`
+	if after != want {
+		t.Errorf("after = %q, want %q", after, want)
+	}
+
+	orderBy, err := b.OrderBy(orders)
+	if err != nil {
+		t.Fatalf("OrderBy failed: %v", err)
+	}
+	if orderBy != "author, edition DESC, id" {
+		t.Errorf("orderBy = %q", orderBy)
+	}
+
+	if _, err := b.After(orders, &repository.Cursor{ID: "some-id"}); err == nil {
+		t.Error("expected error for cursor without values")
+	}
+}
+
+func TestQueryBuilder_Matching(t *testing.T) {
+	expr, err := filter.Parse(`edition > 1 OR title = "Go"`, repository.BookFilterSchema)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	b := &Builder{}
+	conditions, err := b.Matching(repository.ListBooksParams{Filter: expr})
+	if err != nil {
+		t.Fatalf("Matching failed: %v", err)
+	}
+	want := []string{"deleted_at IS NULL", "(edition > $1 OR title = $2)"}
+	if len(conditions) != len(want) || conditions[0] != want[0] || conditions[1] != want[1] {
+		t.Errorf("matching = %q, want %q", conditions, want)
+	}
+
+	conditions, err = (&Builder{}).Matching(repository.ListBooksParams{ShowDeleted: true})
+	if err != nil || len(conditions) != 0 {
+		t.Errorf("matching with ShowDeleted = %q, %v, want no conditions", conditions, err)
+	}
+}
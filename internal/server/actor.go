@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+
+	"github.com/igoventura/go-grpc-library-service/internal/auth"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// actorHeader is the request metadata naming who makes a change.
+	actorHeader = "x-actor"
+	// unverifiedPrefix marks actors taken from the actorHeader, which the
+	// client controls.
+	unverifiedPrefix = "unverified:"
+	// anonymousActor is recorded for requests without an actor header.
+	anonymousActor = "anonymous"
+)
+
+// ActorUnaryInterceptor attributes the writes of each call to its caller,
+// for the book history. Calls authenticated by auth.Authenticator are
+// attributed to their principal. Others fall back to the x-actor header,
+// recorded as "unverified:<name>" since it can name anyone.
+func ActorUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return handler(repository.WithActor(ctx, principal.String()), req)
+	}
+
+	actor := anonymousActor
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(actorHeader); len(values) > 0 && values[0] != "" {
+			actor = unverifiedPrefix + values[0]
+		}
+	}
+	return handler(repository.WithActor(ctx, actor), req)
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/igoventura/go-grpc-library-service/internal/auth"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestActorUnaryInterceptor(t *testing.T) {
+	withHeader := metadata.NewIncomingContext(context.Background(), metadata.Pairs(actorHeader, "alice"))
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no header", context.Background(), "anonymous"},
+		{"header", withHeader, "unverified:alice"},
+		{"principal", auth.WithPrincipal(withHeader, auth.Principal{Subject: "ci", Method: auth.MethodAPIKey}), "api-key:ci"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := func(ctx context.Context, req any) (any, error) {
+				got = repository.ActorFromContext(ctx)
+				return nil, nil
+			}
+			if _, err := ActorUnaryInterceptor(tt.ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil {
+				t.Fatalf("interceptor failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected actor %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"github.com/igoventura/go-grpc-library-service/internal/dbstats"
 	"github.com/igoventura/go-grpc-library-service/internal/repository"
 	"github.com/igoventura/go-grpc-library-service/internal/service"
-	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
 )
 
-func NewLibraryServer(bookRepo repository.BookRepository) v1.LibraryServiceServer {
-	return service.New(bookRepo)
+func NewLibraryServer(bookRepo repository.BookRepository, opts ...service.Option) *service.LibraryServiceServerImpl {
+	return service.New(bookRepo, opts...)
+}
+
+func NewAdminServer(db dbstats.Source, admins []string) *service.AdminServiceServerImpl {
+	return service.NewAdmin(db, admins)
 }
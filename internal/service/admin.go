@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"github.com/igoventura/go-grpc-library-service/internal/auth"
+	"github.com/igoventura/go-grpc-library-service/internal/dbstats"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type AdminServiceServerImpl struct {
+	v1.UnimplementedAdminServiceServer
+
+	db     dbstats.Source
+	admins map[string]bool
+}
+
+// NewAdmin returns the admin service. db is nil for storage without a
+// connection pool, such as the in-memory repository. Only the principals
+// named in admins, as formatted by auth.Principal.String, may call it; the
+// service shares the listener of the catalog, whose clients must not see it.
+func NewAdmin(db dbstats.Source, admins []string) *AdminServiceServerImpl {
+	s := &AdminServiceServerImpl{db: db, admins: make(map[string]bool)}
+	for _, admin := range admins {
+		s.admins[admin] = true
+	}
+	return s
+}
+
+// authorize fails unless the caller is an authenticated admin.
+func (s *AdminServiceServerImpl) authorize(ctx context.Context) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "admin calls require authentication as a principal listed in auth.admins")
+	}
+	if !s.admins[principal.String()] {
+		return status.Errorf(codes.PermissionDenied, "%s is not listed in auth.admins", principal)
+	}
+	return nil
+}
+
+func (s *AdminServiceServerImpl) GetDatabaseStats(ctx context.Context, req *v1.GetDatabaseStatsRequest) (*v1.DatabaseStats, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.db == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the storage driver has no database connection pool")
+	}
+
+	stats := s.db.Stats()
+	return &v1.DatabaseStats{
+		MaxOpenConnections: int32(stats.MaxOpenConnections),
+		OpenConnections:    int32(stats.OpenConnections),
+		InUse:              int32(stats.InUse),
+		Idle:               int32(stats.Idle),
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       durationpb.New(stats.WaitDuration),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/auth"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fixedStats sql.DBStats
+
+func (f fixedStats) Stats() sql.DBStats {
+	return sql.DBStats(f)
+}
+
+// adminContext is the context of a call made by the api-key:ops admin.
+var adminContext = auth.WithPrincipal(context.Background(), auth.Principal{Subject: "ops", Method: auth.MethodAPIKey})
+
+func TestAdminServiceServerImpl_GetDatabaseStats(t *testing.T) {
+	svc := NewAdmin(fixedStats{
+		MaxOpenConnections: 25,
+		OpenConnections:    7,
+		InUse:              5,
+		Idle:               2,
+		WaitCount:          12,
+		WaitDuration:       1500 * time.Millisecond,
+		MaxLifetimeClosed:  3,
+	}, []string{"api-key:ops"})
+
+	got, err := svc.GetDatabaseStats(adminContext, &v1.GetDatabaseStatsRequest{})
+	if err != nil {
+		t.Fatalf("GetDatabaseStats failed: %v", err)
+	}
+	if got.MaxOpenConnections != 25 || got.OpenConnections != 7 || got.InUse != 5 || got.Idle != 2 {
+		t.Errorf("Unexpected connection counts: %v", got)
+	}
+	if got.WaitCount != 12 || got.WaitDuration.AsDuration() != 1500*time.Millisecond {
+		t.Errorf("Expected 12 waits totalling 1.5s, got %d totalling %v", got.WaitCount, got.WaitDuration.AsDuration())
+	}
+	if got.MaxLifetimeClosed != 3 {
+		t.Errorf("Expected 3 connections closed for their lifetime, got %d", got.MaxLifetimeClosed)
+	}
+}
+
+func TestAdminServiceServerImpl_GetDatabaseStats_NoPool(t *testing.T) {
+	_, err := NewAdmin(nil, []string{"api-key:ops"}).GetDatabaseStats(adminContext, &v1.GetDatabaseStatsRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestAdminServiceServerImpl_GetDatabaseStats_NotAdmin(t *testing.T) {
+	svc := NewAdmin(fixedStats{}, []string{"api-key:ops"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"unauthenticated", context.Background()},
+		{"catalog client", auth.WithPrincipal(context.Background(), auth.Principal{Subject: "ci", Method: auth.MethodAPIKey})},
+		{"token named like the admin key", auth.WithPrincipal(context.Background(), auth.Principal{Subject: "api-key:ops", Method: auth.MethodJWT})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GetDatabaseStats(tt.ctx, &v1.GetDatabaseStatsRequest{}); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("Expected PermissionDenied, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *LibraryServiceServerImpl) BatchCreateBooks(ctx context.Context, req *v1.BatchCreateBooksRequest) (*v1.BatchCreateBooksResponse, error) {
+	if err := validateBatchCreateBooksRequest(req); err != nil {
+		return nil, err
+	}
+
+	results := make([]*v1.BatchBookResult, len(req.Requests))
+	var (
+		books   []*domain.Book
+		indexes []int
+	)
+	for i, item := range req.Requests {
+		if err := validateCreateBookRequest(item); err != nil {
+			results[i] = errorResult(err)
+			continue
+		}
+		isbn, _ := domain.ParseISBN(item.Isbn)
+		books = append(books, &domain.Book{
+			Title:   item.Title,
+			Author:  item.Author,
+			Edition: int(item.Edition),
+			ISBN:    isbn.String(),
+		})
+		indexes = append(indexes, i)
+	}
+
+	defer s.lockWrites()()
+	created, err := s.repo.CreateBooks(ctx, books)
+	switch {
+	case err == nil:
+		for i, book := range created {
+			results[indexes[i]] = &v1.BatchBookResult{Book: domain.BookToDto(book)}
+		}
+		s.publishChanges(v1.BookEvent_CREATED, created...)
+	case !req.AllowPartial && errors.Is(err, repository.ErrAlreadyExists):
+		return nil, status.Errorf(codes.AlreadyExists, "batch contains an existing or repeated ISBN and edition: %v", err)
+	case !req.AllowPartial:
+		return nil, writeError("create books", err)
+	default:
+		// The single transaction failed as a whole; retry the items one by one
+		// so each result says exactly which book was the problem.
+		for i, book := range books {
+			result, err := s.repo.CreateBook(ctx, book)
+			switch {
+			case err == nil:
+				results[indexes[i]] = &v1.BatchBookResult{Book: domain.BookToDto(result)}
+				s.publishChanges(v1.BookEvent_CREATED, result)
+			case errors.Is(err, repository.ErrAlreadyExists):
+				results[indexes[i]] = errorResult(alreadyExists(err, book))
+			default:
+				results[indexes[i]] = errorResult(writeError("create book", err))
+			}
+		}
+	}
+
+	return &v1.BatchCreateBooksResponse{Results: results}, nil
+}
+
+func (s *LibraryServiceServerImpl) BatchGetBooks(ctx context.Context, req *v1.BatchGetBooksRequest) (*v1.BatchGetBooksResponse, error) {
+	if err := validateBatchIDs(req.Ids); err != nil {
+		return nil, err
+	}
+
+	books, err := s.repo.GetBooksByIDs(ctx, req.Ids)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get books: %v", err)
+	}
+
+	byID := make(map[string]*domain.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	var missing []string
+	results := make([]*v1.BatchBookResult, len(req.Ids))
+	for i, id := range req.Ids {
+		book, ok := byID[id]
+		if !ok || book.DeletedAt != nil {
+			missing = append(missing, id)
+			results[i] = errorResult(status.Errorf(codes.NotFound, "book not found: %s", id))
+			continue
+		}
+		results[i] = &v1.BatchBookResult{Book: domain.BookToDto(book)}
+	}
+
+	if len(missing) > 0 && !req.AllowPartial {
+		return nil, status.Errorf(codes.NotFound, "books not found: %s", strings.Join(missing, ", "))
+	}
+
+	return &v1.BatchGetBooksResponse{Results: results}, nil
+}
+
+func (s *LibraryServiceServerImpl) BatchDeleteBooks(ctx context.Context, req *v1.BatchDeleteBooksRequest) (*v1.BatchDeleteBooksResponse, error) {
+	if err := validateBatchIDs(req.Ids); err != nil {
+		return nil, err
+	}
+
+	defer s.lockWrites()()
+	deleted, err := s.repo.DeleteBooks(ctx, req.Ids, req.AllowPartial)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "one or more books not found, nothing was deleted")
+		}
+		return nil, writeError("delete books", err)
+	}
+
+	wasDeleted := make(map[string]bool, len(deleted))
+	for _, id := range deleted {
+		wasDeleted[id] = true
+		s.publishChanges(v1.BookEvent_DELETED, &domain.Book{ID: id})
+	}
+
+	results := make([]*v1.BatchBookResult, len(req.Ids))
+	for i, id := range req.Ids {
+		if !wasDeleted[id] {
+			results[i] = errorResult(status.Errorf(codes.NotFound, "book not found: %s", id))
+			continue
+		}
+		results[i] = &v1.BatchBookResult{}
+	}
+
+	return &v1.BatchDeleteBooksResponse{Results: results}, nil
+}
+
+func errorResult(err error) *v1.BatchBookResult {
+	st := status.Convert(err)
+	return &v1.BatchBookResult{Code: int32(st.Code()), Message: st.Message()}
+}
@@ -3,47 +3,129 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
 	"github.com/igoventura/go-grpc-library-service/internal/repository"
 	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+
+	bookResourceType = "library.v1.Book"
+)
+
 type LibraryServiceServerImpl struct {
 	v1.UnimplementedLibraryServiceServer
 
-	repo repository.BookRepository
+	repo       repository.BookRepository
+	pageTokens pageTokenCodec
+	// changes is nil unless WatchBooks is enabled.
+	changes          *changeFeed
+	deletedRetention time.Duration
+	purgeBatchSize   int
+}
+
+// Option configures a LibraryServiceServerImpl.
+type Option func(*options)
+
+type options struct {
+	pageTokenSecret  []byte
+	deletedRetention time.Duration
+	watchBooks       bool
 }
 
-func New(bookRepo repository.BookRepository) *LibraryServiceServerImpl {
-	return &LibraryServiceServerImpl{
-		repo: bookRepo,
+// WithPageTokenSecret sets the key used to sign ListBooks page tokens.
+// Replicas behind the same load balancer must share it; when unset a random
+// key is generated and tokens do not survive a restart.
+func WithPageTokenSecret(secret []byte) Option {
+	return func(o *options) {
+		o.pageTokenSecret = secret
 	}
 }
 
+// WithDeletedRetention sets how long soft-deleted books are kept before the
+// purger removes them for good. It defaults to 30 days.
+func WithDeletedRetention(retention time.Duration) Option {
+	return func(o *options) {
+		o.deletedRetention = retention
+	}
+}
+
+// WithWatchBooks serves WatchBooks. Its change feed only holds the writes
+// made through this server since it started, so it is only complete when a
+// single replica serves the catalog. Without this option WatchBooks fails
+// with codes.Unimplemented.
+func WithWatchBooks() Option {
+	return func(o *options) {
+		o.watchBooks = true
+	}
+}
+
+func New(bookRepo repository.BookRepository, opts ...Option) *LibraryServiceServerImpl {
+	o := options{deletedRetention: defaultDeletedRetention}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &LibraryServiceServerImpl{
+		repo:             bookRepo,
+		pageTokens:       newPageTokenCodec(o.pageTokenSecret),
+		deletedRetention: o.deletedRetention,
+		purgeBatchSize:   purgeBatchSize,
+	}
+	if o.watchBooks {
+		s.changes = newChangeFeed()
+	}
+	return s
+}
+
 func (s *LibraryServiceServerImpl) CreateBook(ctx context.Context, req *v1.CreateBookRequest) (*v1.Book, error) {
+	if err := validateCreateBookRequest(req); err != nil {
+		return nil, err
+	}
+
+	isbn, _ := domain.ParseISBN(req.Isbn)
+
 	domainBook := &domain.Book{
 		Title:   req.Title,
 		Author:  req.Author,
 		Edition: int(req.Edition),
-		ISBN:    req.Isbn,
+		ISBN:    isbn.String(),
 	}
 
+	defer s.lockWrites()()
 	createdBook, err := s.repo.CreateBook(ctx, domainBook)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create book: %v", err)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, alreadyExists(err, domainBook)
+		}
+		return nil, writeError("create book", err)
 	}
 
+	s.publishChanges(v1.BookEvent_CREATED, createdBook)
+
 	responseDto := domain.BookToDto(createdBook)
 
 	return responseDto, nil
 }
 
 func (s *LibraryServiceServerImpl) GetBook(ctx context.Context, req *v1.GetBookRequest) (*v1.Book, error) {
-	book, err := s.repo.GetBookByID(ctx, req.Id)
+	if err := validateGetBookRequest(req); err != nil {
+		return nil, err
+	}
+
+	book, err := s.getBook(ctx, req.Id, req.ShowDeleted)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
@@ -56,56 +138,359 @@ func (s *LibraryServiceServerImpl) GetBook(ctx context.Context, req *v1.GetBookR
 	return responseDto, nil
 }
 
+// writeError reports a failed write. A transaction that kept conflicting
+// with concurrent writers is Aborted, telling the client it may retry;
+// anything else is Internal.
+func writeError(action string, err error) error {
+	if errors.Is(err, repository.ErrRetriesExhausted) {
+		return status.Errorf(codes.Aborted, "failed to %s, too much contention: %v", action, err)
+	}
+	return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+}
+
+// alreadyExists builds the AlreadyExists status for a write that collided
+// with another book, pointing at that book in a ResourceInfo detail.
+func alreadyExists(err error, book *domain.Book) error {
+	st := status.Newf(codes.AlreadyExists, "a book with ISBN %s and edition %d already exists", book.ISBN, book.Edition)
+
+	var conflict *repository.ConflictError
+	if errors.As(err, &conflict) {
+		detailed, detailErr := st.WithDetails(&errdetails.ResourceInfo{
+			ResourceType: bookResourceType,
+			ResourceName: conflict.ExistingID,
+			Description:  "existing book with the same ISBN and edition",
+		})
+		if detailErr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// getBook looks a book up by ID or, when name parses as an ISBN in any
+// format, returns the earliest edition stored under that ISBN. Soft-deleted
+// books are reported as missing unless showDeleted is set.
+func (s *LibraryServiceServerImpl) getBook(ctx context.Context, name string, showDeleted bool) (*domain.Book, error) {
+	isbn, err := domain.ParseISBN(name)
+	if err != nil {
+		book, err := s.repo.GetBookByID(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if book.DeletedAt != nil && !showDeleted {
+			return nil, repository.ErrNotFound
+		}
+		return book, nil
+	}
+
+	books, err := s.repo.GetBookByISBN(ctx, isbn.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range books {
+		if book.DeletedAt == nil || showDeleted {
+			return book, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (s *LibraryServiceServerImpl) GetBookByIsbn(ctx context.Context, req *v1.GetBookByIsbnRequest) (*v1.GetBookByIsbnResponse, error) {
+	if err := validateGetBookByIsbnRequest(req); err != nil {
+		return nil, err
+	}
+
+	isbn, _ := domain.ParseISBN(req.Isbn)
+
+	books, err := s.repo.GetBookByISBN(ctx, isbn.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "no book with ISBN %s", isbn)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get book by ISBN: %v", err)
+	}
+
+	response := &v1.GetBookByIsbnResponse{}
+	for _, book := range books {
+		if book.DeletedAt == nil {
+			response.Books = append(response.Books, domain.BookToDto(book))
+		}
+	}
+	if len(response.Books) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no book with ISBN %s", isbn)
+	}
+	return response, nil
+}
+
 func (s *LibraryServiceServerImpl) UpdateBook(ctx context.Context, req *v1.UpdateBookRequest) (*v1.Book, error) {
-	domainBook := &domain.Book{
-		ID:      req.Id,
-		Title:   req.Title,
-		Author:  req.Author,
-		Edition: int(req.Edition),
-		ISBN:    req.Isbn,
+	fields, expectedVersion, err := validateUpdateBookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.repo.GetBookByID(ctx, req.Id)
+	if err == nil && book.DeletedAt != nil {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get book: %v", err)
+	}
+
+	if expectedVersion != 0 && expectedVersion != book.Version {
+		return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.Id)
+	}
+	if len(fields) == 0 {
+		return domain.BookToDto(book), nil
 	}
 
-	updatedBook, err := s.repo.UpdateBook(ctx, domainBook)
+	for _, field := range fields {
+		switch field {
+		case repository.FieldTitle:
+			book.Title = req.Title
+		case repository.FieldAuthor:
+			book.Author = req.Author
+		case repository.FieldEdition:
+			book.Edition = int(req.Edition)
+		case repository.FieldISBN:
+			isbn, _ := domain.ParseISBN(req.Isbn)
+			book.ISBN = isbn.String()
+		}
+	}
+
+	// Only the masked columns are written, so without an etag the update can
+	// safely race with other writers.
+	defer s.lockWrites()()
+	updatedBook, err := s.repo.UpdateBook(ctx, book, fields, expectedVersion)
 
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to update book: %v", err)
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.Id)
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, alreadyExists(err, book)
+		}
+		return nil, writeError("update book", err)
 	}
 
+	s.publishChanges(v1.BookEvent_UPDATED, updatedBook)
+
 	responseDto := domain.BookToDto(updatedBook)
 	return responseDto, nil
 }
 
+// updateFields resolves the fields an UpdateBookRequest changes. Following
+// AIP-134, a missing mask means every non-empty field and "*" means all of
+// them.
+func updateFields(req *v1.UpdateBookRequest) ([]string, error) {
+	if req.UpdateMask == nil {
+		var fields []string
+		if req.Title != "" {
+			fields = append(fields, repository.FieldTitle)
+		}
+		if req.Author != "" {
+			fields = append(fields, repository.FieldAuthor)
+		}
+		if req.Edition != 0 {
+			fields = append(fields, repository.FieldEdition)
+		}
+		if req.Isbn != "" {
+			fields = append(fields, repository.FieldISBN)
+		}
+		return fields, nil
+	}
+
+	paths := req.UpdateMask.GetPaths()
+	if len(paths) == 1 && paths[0] == "*" {
+		return repository.BookMutableFields, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !slices.Contains(repository.BookMutableFields, path) {
+			return nil, fmt.Errorf("unknown or immutable field %q", path)
+		}
+		if !seen[path] {
+			seen[path] = true
+			fields = append(fields, path)
+		}
+	}
+	return fields, nil
+}
+
 func (s *LibraryServiceServerImpl) DeleteBook(ctx context.Context, req *v1.DeleteBookRequest) (*emptypb.Empty, error) {
-	err := s.repo.DeleteBook(ctx, req.Id)
+	expectedVersion, err := validateDeleteBookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.lockWrites()()
+	err = s.repo.DeleteBook(ctx, req.Id, expectedVersion)
 
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to delete book: %v", err)
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.Id)
+		}
+		return nil, writeError("delete book", err)
 	}
 
+	s.publishChanges(v1.BookEvent_DELETED, &domain.Book{ID: req.Id})
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *LibraryServiceServerImpl) UndeleteBook(ctx context.Context, req *v1.UndeleteBookRequest) (*v1.Book, error) {
+	expectedVersion, err := validateUndeleteBookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.lockWrites()()
+	book, err := s.repo.UndeleteBook(ctx, req.Id, expectedVersion)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.Id)
+		}
+		if errors.Is(err, repository.ErrNotDeleted) {
+			return nil, status.Errorf(codes.FailedPrecondition, "book %s is not deleted", req.Id)
+		}
+		return nil, writeError("undelete book", err)
+	}
+
+	s.publishChanges(v1.BookEvent_UNDELETED, book)
+
+	return domain.BookToDto(book), nil
+}
+
+func (s *LibraryServiceServerImpl) PurgeBook(ctx context.Context, req *v1.PurgeBookRequest) (*emptypb.Empty, error) {
+	expectedVersion, err := validatePurgeBookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.lockWrites()()
+	err = s.repo.PurgeBook(ctx, req.Id, expectedVersion)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.Id)
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.Id)
+		}
+		if errors.Is(err, repository.ErrNotDeleted) {
+			return nil, status.Errorf(codes.FailedPrecondition, "book %s must be deleted before it can be purged", req.Id)
+		}
+		return nil, writeError("purge book", err)
+	}
+
+	s.publishChanges(v1.BookEvent_PURGED, &domain.Book{ID: req.Id})
+
 	return &emptypb.Empty{}, nil
 }
 
 func (s *LibraryServiceServerImpl) ListBooks(ctx context.Context, req *v1.ListBooksRequest) (*v1.ListBooksResponse, error) {
-	response := &v1.ListBooksResponse{}
-	books, err := s.repo.ListBooks(ctx)
+	query, err := s.validateListBooksRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
+	books, err := s.repo.ListBooks(ctx, query.params)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list books: %v", err)
 	}
 
-	for _, book := range books {
-		if book.ISBN == "" || book.Title == "" {
-			continue
+	response := &v1.ListBooksResponse{}
+
+	// Counting scans every matching row, so it is done once per listing, on
+	// the first page, and skipped when that page holds every match.
+	if query.params.After == nil {
+		totalSize := len(books)
+		if len(books) > query.pageSize {
+			if totalSize, err = s.repo.CountBooks(ctx, query.params); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to count books: %v", err)
+			}
+		}
+		response.TotalSize = int32(totalSize)
+	}
+
+	if len(books) > query.pageSize {
+		books = books[:query.pageSize]
+		nextPageToken, err := s.encodeCursor(books[len(books)-1], query.fingerprint, query.params.OrderBy)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create page token: %v", err)
 		}
+		response.NextPageToken = nextPageToken
+	}
+
+	for _, book := range books {
 		bookDto := domain.BookToDto(book)
 		response.Books = append(response.Books, bookDto)
 	}
 
 	return response, nil
 }
+
+// StreamBooks sends matching books as they are read from the repository
+// rather than collecting them first. Send blocks while the client's flow
+// control window is full, which in turn stops reading from the database.
+func (s *LibraryServiceServerImpl) StreamBooks(req *v1.StreamBooksRequest, stream grpc.ServerStreamingServer[v1.Book]) error {
+	params, err := validateStreamBooksRequest(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for book, err := range s.repo.StreamBooks(ctx, params) {
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return status.Errorf(codes.Internal, "failed to stream books: %v", err)
+		}
+		if err := stream.Send(domain.BookToDto(book)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *LibraryServiceServerImpl) encodeCursor(last *domain.Book, query string, orderBy []filter.Order) (string, error) {
+	token := pageToken{Query: query, LastID: last.ID}
+	for _, order := range orderBy {
+		token.Values = append(token.Values, filter.FormatValue(repository.BookFieldValue(last, order.Field)))
+	}
+	return s.pageTokens.encode(token)
+}
+
+func (s *LibraryServiceServerImpl) decodeCursor(encoded, query string, orderBy []filter.Order) (*repository.Cursor, error) {
+	token, err := s.pageTokens.decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if token.Query != query || len(token.Values) != len(orderBy) {
+		return nil, errInvalidPageToken
+	}
+
+	cursor := &repository.Cursor{ID: token.LastID}
+	for i, order := range orderBy {
+		value, err := repository.BookFilterSchema.ParseValue(order.Field, token.Values[i])
+		if err != nil {
+			return nil, errInvalidPageToken
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	return cursor, nil
+}
@@ -2,69 +2,40 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
 	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/memory"
+	"github.com/igoventura/go-grpc-library-service/internal/repository/repositorytest"
 	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
-// MockBookRepository implements repository.BookRepository for testing
-type MockBookRepository struct {
-	books   map[string]*domain.Book
-	counter int
-}
-
-func NewMockBookRepository() *MockBookRepository {
-	return &MockBookRepository{
-		books: make(map[string]*domain.Book),
-	}
-}
-
-func (m *MockBookRepository) CreateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
-	m.counter++
-	book.ID = "test-id-" + string(rune(m.counter))
-	m.books[book.ID] = book
-	return book, nil
-}
-
-func (m *MockBookRepository) GetBookByID(ctx context.Context, id string) (*domain.Book, error) {
-	book, exists := m.books[id]
-	if !exists {
-		return nil, repository.ErrNotFound
-	}
-	return book, nil
-}
-
-func (m *MockBookRepository) UpdateBook(ctx context.Context, book *domain.Book) (*domain.Book, error) {
-	if _, exists := m.books[book.ID]; !exists {
-		return nil, repository.ErrNotFound
-	}
-	m.books[book.ID] = book
-	return book, nil
-}
-
-func (m *MockBookRepository) DeleteBook(ctx context.Context, id string) error {
-	if _, exists := m.books[id]; !exists {
-		return repository.ErrNotFound
-	}
-	delete(m.books, id)
-	return nil
-}
-
-func (m *MockBookRepository) ListBooks(ctx context.Context) ([]*domain.Book, error) {
-	var books []*domain.Book
-	for _, book := range m.books {
-		books = append(books, book)
+// storedBooks counts every book in repo, deleted or not.
+func storedBooks(t *testing.T, repo repository.BookRepository) int {
+	t.Helper()
+	count, err := repo.CountBooks(context.Background(), repository.ListBooksParams{ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("CountBooks failed: %v", err)
 	}
-	return books, nil
+	return count
 }
 
 func TestLibraryServiceServerImpl_CreateBook(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	req := &v1.CreateBookRequest{
@@ -88,17 +59,139 @@ func TestLibraryServiceServerImpl_CreateBook(t *testing.T) {
 	if book.Edition != req.Edition {
 		t.Errorf("Expected edition %v, got %v", req.Edition, book.Edition)
 	}
-	if book.Isbn != req.Isbn {
-		t.Errorf("Expected ISBN %q, got %q", req.Isbn, book.Isbn)
+	if book.Isbn != "9780134190440" {
+		t.Errorf("Expected canonical ISBN %q, got %q", "9780134190440", book.Isbn)
 	}
 	if book.Id == "" {
 		t.Error("Expected book ID to be generated, got empty string")
 	}
+	if book.CreateTime == nil || book.CreateTime.AsTime().IsZero() {
+		t.Error("Expected create_time to be set")
+	}
+	if book.UpdateTime == nil || book.UpdateTime.AsTime().IsZero() {
+		t.Error("Expected update_time to be set")
+	}
+}
+
+func TestLibraryServiceServerImpl_CreateBook_AlreadyExists(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	existing, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: "978-0134190440"})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	// The same ISBN in another format is still a duplicate.
+	_, err = service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Copy", Author: "Author", Edition: 1, Isbn: "0134190440"})
+	st, _ := status.FromError(err)
+	if st.Code() != codes.AlreadyExists {
+		t.Fatalf("Expected AlreadyExists, got %v", err)
+	}
+
+	var resourceName string
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ResourceInfo); ok {
+			resourceName = info.ResourceName
+		}
+	}
+	if resourceName != existing.Id {
+		t.Errorf("Expected ResourceInfo naming %q, got %q", existing.Id, resourceName)
+	}
+
+	// A new edition of the same ISBN is allowed.
+	second, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 2, Isbn: "978-0134190440"})
+	if err != nil {
+		t.Fatalf("CreateBook for second edition failed: %v", err)
+	}
+
+	// Updating it into the first edition collides again.
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: second.Id, Edition: 1})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists on update, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_CreateBook_Validation(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	req := &v1.CreateBookRequest{
+		Title:   "  ",
+		Author:  "Alan Donovan",
+		Edition: -1,
+		Isbn:    "not-an-isbn",
+	}
+
+	_, err := service.CreateBook(ctx, req)
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+
+	violated := make(map[string]bool)
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				violated[violation.Field] = true
+			}
+		}
+	}
+
+	for _, field := range []string{"title", "edition", "isbn"} {
+		if !violated[field] {
+			t.Errorf("Expected a field violation for %q, got %v", field, violated)
+		}
+	}
+	if violated["author"] {
+		t.Error("Did not expect a field violation for author")
+	}
+	if stored := storedBooks(t, bookRepo); stored != 0 {
+		t.Errorf("Expected no book to be stored, got %d", stored)
+	}
+}
+
+func TestLibraryServiceServerImpl_UpdateBook_Validation(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	createdBook, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(0)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	// Masked fields are validated even when empty...
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{
+		Id:         createdBook.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"author"}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty masked author, got %v", err)
+	}
+
+	// ...while unmasked ones are ignored.
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{
+		Id:         createdBook.Id,
+		Title:      "New Title",
+		Isbn:       "garbage",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	if err != nil {
+		t.Errorf("UpdateBook failed: %v", err)
+	}
+
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Title: "New Title"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for missing id, got %v", err)
+	}
 }
 
 func TestLibraryServiceServerImpl_GetBook(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	// First create a book
@@ -128,9 +221,75 @@ func TestLibraryServiceServerImpl_GetBook(t *testing.T) {
 	}
 }
 
+func TestLibraryServiceServerImpl_GetBook_ByISBN(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	createdBook, err := service.CreateBook(ctx, &v1.CreateBookRequest{
+		Title:   "The Go Programming Language",
+		Author:  "Alan Donovan",
+		Edition: 1,
+		Isbn:    "0-13-419044-0",
+	})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	if createdBook.Isbn != "9780134190440" {
+		t.Errorf("Expected ISBN-10 to be stored as ISBN-13 %q, got %q", "9780134190440", createdBook.Isbn)
+	}
+
+	for _, isbn := range []string{"9780134190440", "978-0-13-419044-0", "0134190440"} {
+		book, err := service.GetBook(ctx, &v1.GetBookRequest{Id: isbn})
+		if err != nil {
+			t.Errorf("GetBook(%q) failed: %v", isbn, err)
+			continue
+		}
+		if book.Id != createdBook.Id {
+			t.Errorf("GetBook(%q) returned %q, want %q", isbn, book.Id, createdBook.Id)
+		}
+	}
+
+	_, err = service.GetBook(ctx, &v1.GetBookRequest{Id: "9780132350884"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for unknown ISBN, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_GetBookByIsbn(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	for _, edition := range []int32{2, 1} {
+		_, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Clean Code", Author: "Robert Martin", Edition: edition, Isbn: "978-0132350884"})
+		if err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	response, err := service.GetBookByIsbn(ctx, &v1.GetBookByIsbnRequest{Isbn: "0-13-235088-2"})
+	if err != nil {
+		t.Fatalf("GetBookByIsbn failed: %v", err)
+	}
+	if len(response.Books) != 2 || response.Books[0].Edition != 1 || response.Books[1].Edition != 2 {
+		t.Errorf("Expected editions 1 and 2 in order, got %v", response.Books)
+	}
+
+	_, err = service.GetBookByIsbn(ctx, &v1.GetBookByIsbnRequest{Isbn: "978-0134190440"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	_, err = service.GetBookByIsbn(ctx, &v1.GetBookByIsbnRequest{Isbn: "12345"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
 func TestLibraryServiceServerImpl_GetBook_NotFound(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	getReq := &v1.GetBookRequest{Id: "non-existent-id"}
@@ -150,8 +309,8 @@ func TestLibraryServiceServerImpl_GetBook_NotFound(t *testing.T) {
 }
 
 func TestLibraryServiceServerImpl_UpdateBook(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	// First create a book
@@ -159,7 +318,7 @@ func TestLibraryServiceServerImpl_UpdateBook(t *testing.T) {
 		Title:   "Original Title",
 		Author:  "Original Author",
 		Edition: 1,
-		Isbn:    "978-0000000000",
+		Isbn:    repositorytest.ISBN(0),
 	}
 	createdBook, err := service.CreateBook(ctx, createReq)
 	if err != nil {
@@ -172,7 +331,7 @@ func TestLibraryServiceServerImpl_UpdateBook(t *testing.T) {
 		Title:   "Updated Title",
 		Author:  "Updated Author",
 		Edition: 2,
-		Isbn:    "978-1111111111",
+		Isbn:    repositorytest.ISBN(111),
 	}
 	updatedBook, err := service.UpdateBook(ctx, updateReq)
 	if err != nil {
@@ -185,11 +344,122 @@ func TestLibraryServiceServerImpl_UpdateBook(t *testing.T) {
 	if updatedBook.Author != updateReq.Author {
 		t.Errorf("Expected author %q, got %q", updateReq.Author, updatedBook.Author)
 	}
+	if !updatedBook.CreateTime.AsTime().Equal(createdBook.CreateTime.AsTime()) {
+		t.Errorf("Expected create_time %v to be preserved, got %v", createdBook.CreateTime.AsTime(), updatedBook.CreateTime.AsTime())
+	}
+	if updatedBook.UpdateTime.AsTime().Before(createdBook.UpdateTime.AsTime()) {
+		t.Errorf("Expected update_time to advance, got %v before %v", updatedBook.UpdateTime.AsTime(), createdBook.UpdateTime.AsTime())
+	}
+}
+
+func TestLibraryServiceServerImpl_UpdateBook_UpdateMask(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	createReq := &v1.CreateBookRequest{
+		Title:   "The Go Programing Language",
+		Author:  "Alan Donovan",
+		Edition: 1,
+		Isbn:    "978-0134190440",
+	}
+	createdBook, err := service.CreateBook(ctx, createReq)
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	// Only the title is in the mask, so the empty ISBN must not be written.
+	updateReq := &v1.UpdateBookRequest{
+		Id:         createdBook.Id,
+		Title:      "The Go Programming Language",
+		Edition:    7,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	}
+	updatedBook, err := service.UpdateBook(ctx, updateReq)
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	if updatedBook.Title != updateReq.Title {
+		t.Errorf("Expected title %q, got %q", updateReq.Title, updatedBook.Title)
+	}
+	if updatedBook.Isbn != createdBook.Isbn {
+		t.Errorf("Expected ISBN %q to be preserved, got %q", createdBook.Isbn, updatedBook.Isbn)
+	}
+	if updatedBook.Edition != createReq.Edition {
+		t.Errorf("Expected edition %d to be preserved, got %d", createReq.Edition, updatedBook.Edition)
+	}
+
+	// Without a mask only the populated fields are applied.
+	updatedBook, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: createdBook.Id, Edition: 2})
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if updatedBook.Edition != 2 || updatedBook.Isbn != createdBook.Isbn || updatedBook.Title != updateReq.Title {
+		t.Errorf("Unexpected book after implicit mask update: %v", updatedBook)
+	}
+}
+
+func TestLibraryServiceServerImpl_UpdateBook_NoOp(t *testing.T) {
+	service := New(memory.NewBookRepository())
+	ctx := context.Background()
+
+	book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	if book, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: book.Id, Title: "Renamed"}); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	requests := map[string]*v1.UpdateBookRequest{
+		"no fields":            {Id: book.Id},
+		"empty mask":           {Id: book.Id, Title: "Ignored", UpdateMask: &fieldmaskpb.FieldMask{}},
+		"no fields with etag":  {Id: book.Id, Etag: book.Etag},
+		"empty mask with etag": {Id: book.Id, Etag: book.Etag, UpdateMask: &fieldmaskpb.FieldMask{}},
+	}
+	for name, req := range requests {
+		got, err := service.UpdateBook(ctx, req)
+		if err != nil {
+			t.Fatalf("%s: UpdateBook failed: %v", name, err)
+		}
+		if got.Etag != book.Etag || got.Title != book.Title {
+			t.Errorf("%s: expected the stored book with etag %s, got %v", name, book.Etag, got)
+		}
+	}
+
+	// The returned etag is usable for the next update.
+	if _, err := service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: book.Id, Title: "Again", Etag: book.Etag}); err != nil {
+		t.Errorf("Expected the etag of a no-op update to be accepted, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_UpdateBook_InvalidUpdateMask(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	createdBook, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(0)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	for _, paths := range [][]string{{"publisher"}, {"id"}, {"title", "*"}} {
+		updateReq := &v1.UpdateBookRequest{
+			Id:         createdBook.Id,
+			Title:      "New Title",
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+		}
+		_, err := service.UpdateBook(ctx, updateReq)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for paths %v, got %v", paths, err)
+		}
+	}
 }
 
 func TestLibraryServiceServerImpl_UpdateBook_NotFound(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	updateReq := &v1.UpdateBookRequest{
@@ -197,7 +467,7 @@ func TestLibraryServiceServerImpl_UpdateBook_NotFound(t *testing.T) {
 		Title:   "Some Title",
 		Author:  "Some Author",
 		Edition: 1,
-		Isbn:    "978-0000000000",
+		Isbn:    repositorytest.ISBN(0),
 	}
 	_, err := service.UpdateBook(ctx, updateReq)
 
@@ -214,9 +484,35 @@ func TestLibraryServiceServerImpl_UpdateBook_NotFound(t *testing.T) {
 	}
 }
 
+// contendedRepository fails every UpdateBook as if its transaction kept
+// losing to concurrent writers.
+type contendedRepository struct {
+	repository.BookRepository
+}
+
+func (contendedRepository) UpdateBook(context.Context, *domain.Book, []string, int64) (*domain.Book, error) {
+	return nil, repository.ErrRetriesExhausted
+}
+
+func TestLibraryServiceServerImpl_UpdateBook_RetriesExhausted(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(contendedRepository{bookRepo})
+	ctx := context.Background()
+
+	book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(0)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: book.Id, Title: "New Title"})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted, got %v", err)
+	}
+}
+
 func TestLibraryServiceServerImpl_DeleteBook(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	// First create a book
@@ -224,7 +520,7 @@ func TestLibraryServiceServerImpl_DeleteBook(t *testing.T) {
 		Title:   "Book to Delete",
 		Author:  "Some Author",
 		Edition: 1,
-		Isbn:    "978-0000000000",
+		Isbn:    repositorytest.ISBN(0),
 	}
 	createdBook, err := service.CreateBook(ctx, createReq)
 	if err != nil {
@@ -246,9 +542,49 @@ func TestLibraryServiceServerImpl_DeleteBook(t *testing.T) {
 	}
 }
 
+func TestLibraryServiceServerImpl_ETag(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	createdBook, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(0)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	staleETag := createdBook.Etag
+
+	updatedBook, err := service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: createdBook.Id, Title: "First edit", Etag: staleETag})
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if updatedBook.Etag == staleETag {
+		t.Errorf("Expected etag to change after update, still %q", updatedBook.Etag)
+	}
+
+	// A second librarian still holding the original etag must not clobber the edit.
+	_, err = service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: createdBook.Id, Title: "Second edit", Etag: staleETag})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted for stale etag on update, got %v", err)
+	}
+
+	_, err = service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: createdBook.Id, Etag: staleETag})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted for stale etag on delete, got %v", err)
+	}
+
+	_, err = service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: createdBook.Id, Etag: "not-an-etag"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for malformed etag, got %v", err)
+	}
+
+	if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: createdBook.Id, Etag: updatedBook.Etag}); err != nil {
+		t.Errorf("DeleteBook with current etag failed: %v", err)
+	}
+}
+
 func TestLibraryServiceServerImpl_DeleteBook_NotFound(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
 	deleteReq := &v1.DeleteBookRequest{Id: "non-existent-id"}
@@ -267,34 +603,923 @@ func TestLibraryServiceServerImpl_DeleteBook_NotFound(t *testing.T) {
 	}
 }
 
-func TestLibraryServiceServerImpl_ListBooks(t *testing.T) {
-	mockRepo := NewMockBookRepository()
-	service := New(mockRepo)
+func TestLibraryServiceServerImpl_SoftDelete(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
 	ctx := context.Background()
 
-	// Create some test books
-	books := []*v1.CreateBookRequest{
-		{Title: "Book 1", Author: "Author 1", Edition: 1, Isbn: "978-0000000001"},
-		{Title: "Book 2", Author: "Author 2", Edition: 1, Isbn: "978-0000000002"},
-		{Title: "", Author: "Author 3", Edition: 1, Isbn: ""}, // This should be filtered out
+	kept, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Kept", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Deleted", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(2)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
 	}
 
-	for _, book := range books {
-		_, err := service.CreateBook(ctx, book)
+	if _, err := service.UndeleteBook(ctx, &v1.UndeleteBookRequest{Id: book.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition undeleting a live book, got %v", err)
+	}
+	if _, err := service.PurgeBook(ctx, &v1.PurgeBookRequest{Id: book.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition purging a live book, got %v", err)
+	}
+
+	if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: book.Id, Etag: book.Etag}); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: book.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound deleting twice, got %v", err)
+	}
+
+	// Hidden from reads unless show_deleted is set.
+	if _, err := service.GetBook(ctx, &v1.GetBookRequest{Id: book.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if _, err := service.GetBookByIsbn(ctx, &v1.GetBookByIsbnRequest{Isbn: book.Isbn}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound by ISBN, got %v", err)
+	}
+	if _, err := service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: book.Id, Title: "Renamed"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound updating a deleted book, got %v", err)
+	}
+	deleted, err := service.GetBook(ctx, &v1.GetBookRequest{Id: book.Id, ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("GetBook with show_deleted failed: %v", err)
+	}
+	if deleted.DeleteTime == nil || deleted.Etag == book.Etag {
+		t.Errorf("Expected a delete_time and a new etag, got %v", deleted)
+	}
+
+	list, err := service.ListBooks(ctx, &v1.ListBooksRequest{})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if len(list.Books) != 1 || list.Books[0].Id != kept.Id || list.TotalSize != 1 {
+		t.Errorf("Expected only the kept book, got %v (total %d)", list.Books, list.TotalSize)
+	}
+	list, err = service.ListBooks(ctx, &v1.ListBooksRequest{ShowDeleted: true})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+	if len(list.Books) != 2 || list.TotalSize != 2 {
+		t.Errorf("Expected both books with show_deleted, got %v (total %d)", list.Books, list.TotalSize)
+	}
+
+	// A deleted book still holds its ISBN and edition.
+	_, err = service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Again", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(2)})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %v", err)
+	}
+
+	if _, err := service.UndeleteBook(ctx, &v1.UndeleteBookRequest{Id: book.Id, Etag: book.Etag}); status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted for a stale etag, got %v", err)
+	}
+	restored, err := service.UndeleteBook(ctx, &v1.UndeleteBookRequest{Id: book.Id, Etag: deleted.Etag})
+	if err != nil {
+		t.Fatalf("UndeleteBook failed: %v", err)
+	}
+	if restored.DeleteTime != nil || restored.Title != "Deleted" {
+		t.Errorf("Expected the restored book, got %v", restored)
+	}
+	if _, err := service.GetBook(ctx, &v1.GetBookRequest{Id: book.Id}); err != nil {
+		t.Errorf("GetBook after undelete failed: %v", err)
+	}
+
+	if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: book.Id}); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+	if _, err := service.PurgeBook(ctx, &v1.PurgeBookRequest{Id: book.Id}); err != nil {
+		t.Fatalf("PurgeBook failed: %v", err)
+	}
+	if _, err := service.GetBook(ctx, &v1.GetBookRequest{Id: book.Id, ShowDeleted: true}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound after purge, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_PurgeExpiredBooks(t *testing.T) {
+	now := time.Now()
+	bookRepo := memory.NewBookRepository(memory.WithClock(func() time.Time { return now }))
+	service := New(bookRepo, WithDeletedRetention(time.Hour))
+	ctx := context.Background()
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)})
 		if err != nil {
 			t.Fatalf("CreateBook failed: %v", err)
 		}
+		ids = append(ids, book.Id)
+	}
+	// Only the first book has been deleted for longer than the retention.
+	for i, deletedAt := range []time.Time{now.Add(-2 * time.Hour), now} {
+		now = deletedAt
+		if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: ids[i]}); err != nil {
+			t.Fatalf("DeleteBook failed: %v", err)
+		}
 	}
 
-	// List books
-	listReq := &v1.ListBooksRequest{}
-	response, err := service.ListBooks(ctx, listReq)
+	purged, err := service.PurgeExpiredBooks(ctx)
 	if err != nil {
-		t.Fatalf("ListBooks failed: %v", err)
+		t.Fatalf("PurgeExpiredBooks failed: %v", err)
 	}
-
-	// Should only return 2 books (the third one has empty title and ISBN)
-	if len(response.Books) != 2 {
-		t.Errorf("Expected 2 books, got %d", len(response.Books))
+	if purged != 1 {
+		t.Errorf("Expected 1 book to be purged, got %d", purged)
+	}
+	if _, err := bookRepo.GetBookByID(ctx, ids[0]); err != repository.ErrNotFound {
+		t.Errorf("Expected the expired book to be purged, got %v", err)
+	}
+	if _, err := bookRepo.GetBookByID(ctx, ids[1]); err != nil {
+		t.Errorf("Expected the recently deleted book to be kept, got %v", err)
+	}
+}
+
+// purgeRecorder records how many books each PurgeDeletedBooks call removed.
+type purgeRecorder struct {
+	repository.BookRepository
+	batches []int
+}
+
+func (r *purgeRecorder) PurgeDeletedBooks(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	purged, err := r.BookRepository.PurgeDeletedBooks(ctx, before, limit)
+	r.batches = append(r.batches, len(purged))
+	return purged, err
+}
+
+func TestLibraryServiceServerImpl_PurgeExpiredBooks_Batches(t *testing.T) {
+	// Every book is deleted longer ago than the retention.
+	now := time.Now().Add(-2 * time.Hour)
+	bookRepo := &purgeRecorder{BookRepository: memory.NewBookRepository(memory.WithClock(func() time.Time { return now }))}
+	service := New(bookRepo, WithDeletedRetention(time.Hour))
+	service.purgeBatchSize = 2
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)})
+		if err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+		if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: book.Id}); err != nil {
+			t.Fatalf("DeleteBook failed: %v", err)
+		}
+	}
+
+	purged, err := service.PurgeExpiredBooks(ctx)
+	if err != nil {
+		t.Fatalf("PurgeExpiredBooks failed: %v", err)
+	}
+	if purged != 5 {
+		t.Errorf("Expected 5 books to be purged, got %d", purged)
+	}
+	if want := []int{2, 2, 1}; !slices.Equal(bookRepo.batches, want) {
+		t.Errorf("Expected batches of %v, got %v", want, bookRepo.batches)
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	// Create some test books
+	books := []*v1.CreateBookRequest{
+		{Title: "Book 1", Author: "Author 1", Edition: 1, Isbn: repositorytest.ISBN(1)},
+		{Title: "Book 2", Author: "Author 2", Edition: 1, Isbn: repositorytest.ISBN(2)},
+	}
+
+	for _, book := range books {
+		_, err := service.CreateBook(ctx, book)
+		if err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	// Books with an empty title and ISBN are rejected up front
+	_, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "", Author: "Author 3", Edition: 1, Isbn: ""})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for incomplete book, got %v", err)
+	}
+
+	// List books
+	listReq := &v1.ListBooksRequest{}
+	response, err := service.ListBooks(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+
+	if len(response.Books) != 2 {
+		t.Errorf("Expected 2 books, got %d", len(response.Books))
+	}
+	if response.TotalSize != 2 {
+		t.Errorf("Expected total size 2, got %d", response.TotalSize)
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks_Pagination(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		req := &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)}
+		if _, err := service.CreateBook(ctx, req); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	pageToken := ""
+	pages := 0
+	for {
+		response, err := service.ListBooks(ctx, &v1.ListBooksRequest{PageSize: 2, PageToken: pageToken})
+		if err != nil {
+			t.Fatalf("ListBooks failed: %v", err)
+		}
+		pages++
+
+		// The total is only counted for the first page.
+		var wantTotal int32
+		if pageToken == "" {
+			wantTotal = 5
+		}
+		if response.TotalSize != wantTotal {
+			t.Errorf("Page %d: expected total size %d, got %d", pages, wantTotal, response.TotalSize)
+		}
+		for _, book := range response.Books {
+			if seen[book.Id] {
+				t.Errorf("Book %q returned twice", book.Id)
+			}
+			seen[book.Id] = true
+		}
+
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	if pages != 3 {
+		t.Errorf("Expected 3 pages, got %d", pages)
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 books across all pages, got %d", len(seen))
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks_InvalidPageToken(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		req := &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)}
+		if _, err := service.CreateBook(ctx, req); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	response, err := service.ListBooks(ctx, &v1.ListBooksRequest{PageSize: 1})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+
+	tampered := []byte(response.NextPageToken)
+	tampered[0] ^= 'A' ^ 'B'
+
+	for _, token := range []string{"garbage", string(tampered)} {
+		_, err = service.ListBooks(ctx, &v1.ListBooksRequest{PageToken: token})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for token %q, got %v", token, err)
+		}
+	}
+
+	// Tokens signed by another server must be rejected as well.
+	other := New(bookRepo, WithPageTokenSecret([]byte("another-secret")))
+	_, err = other.ListBooks(ctx, &v1.ListBooksRequest{PageToken: response.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for foreign token, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks_FilterAndOrder(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	books := []*v1.CreateBookRequest{
+		{Title: "Go in Action", Author: "Donovan", Edition: 2, Isbn: repositorytest.ISBN(1)},
+		{Title: "Concurrency", Author: "Donovan", Edition: 3, Isbn: repositorytest.ISBN(2)},
+		{Title: "Beginnings", Author: "Donovan", Edition: 1, Isbn: repositorytest.ISBN(3)},
+		{Title: "Another", Author: "Kernighan", Edition: 2, Isbn: repositorytest.ISBN(4)},
+		{Title: "Algorithms", Author: "Donovan", Edition: 5, Isbn: repositorytest.ISBN(5)},
+	}
+	for _, book := range books {
+		if _, err := service.CreateBook(ctx, book); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	var titles []string
+	pageToken := ""
+	for {
+		response, err := service.ListBooks(ctx, &v1.ListBooksRequest{
+			Filter:    `author = "Donovan" AND edition > 1`,
+			OrderBy:   "title",
+			PageSize:  2,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			t.Fatalf("ListBooks failed: %v", err)
+		}
+		if pageToken == "" && response.TotalSize != 3 {
+			t.Errorf("Expected total size 3, got %d", response.TotalSize)
+		}
+		for _, book := range response.Books {
+			titles = append(titles, book.Title)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	expected := []string{"Algorithms", "Concurrency", "Go in Action"}
+	if strings.Join(titles, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected titles %v, got %v", expected, titles)
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks_InvalidArguments(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		req := &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)}
+		if _, err := service.CreateBook(ctx, req); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	response, err := service.ListBooks(ctx, &v1.ListBooksRequest{PageSize: 1, OrderBy: "title"})
+	if err != nil {
+		t.Fatalf("ListBooks failed: %v", err)
+	}
+
+	requests := map[string]*v1.ListBooksRequest{
+		"unknown field":       {Filter: `publisher = "x"`},
+		"malformed filter":    {Filter: `author = `},
+		"wrong literal type":  {Filter: `edition > "two"`},
+		"unknown order field": {OrderBy: "publisher"},
+		"bad direction":       {OrderBy: "title sideways"},
+		"token for other query": {
+			OrderBy:   "title desc",
+			PageToken: response.NextPageToken,
+		},
+	}
+	for name, req := range requests {
+		_, err := service.ListBooks(ctx, req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+}
+
+func TestLibraryServiceServerImpl_ListBooks_QueryLimits(t *testing.T) {
+	service := New(memory.NewBookRepository())
+	ctx := context.Background()
+
+	deep := strings.Repeat("(", filter.MaxDepth+1) + `author = "x"` + strings.Repeat(")", filter.MaxDepth+1)
+	tests := []struct {
+		name  string
+		req   *v1.ListBooksRequest
+		field string
+	}{
+		{"long filter", &v1.ListBooksRequest{Filter: strings.Repeat("(", maxQueryLength+1)}, "filter"},
+		{"deep filter", &v1.ListBooksRequest{Filter: deep}, "filter"},
+		{"long order_by", &v1.ListBooksRequest{OrderBy: strings.Repeat("title,", maxQueryLength)}, "order_by"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ListBooks(ctx, tt.req)
+			st, ok := status.FromError(err)
+			if !ok || st.Code() != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument, got %v", err)
+			}
+			var violated []string
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					for _, violation := range badRequest.FieldViolations {
+						violated = append(violated, violation.Field)
+					}
+				}
+			}
+			if len(violated) != 1 || violated[0] != tt.field {
+				t.Errorf("Expected a field violation for %s, got %v", tt.field, violated)
+			}
+		})
+	}
+}
+
+func TestLibraryServiceServerImpl_BatchCreateBooks(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	existing, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Existing", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+
+	requests := []*v1.CreateBookRequest{
+		{Title: "New", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(2)},
+		{Title: "Duplicate", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)},
+		{Title: "", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(3)},
+	}
+
+	// All-or-nothing: the invalid item fails the whole call up front.
+	_, err = service.BatchCreateBooks(ctx, &v1.BatchCreateBooksRequest{Requests: requests})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+
+	// All-or-nothing: the duplicate fails the call and nothing is stored.
+	_, err = service.BatchCreateBooks(ctx, &v1.BatchCreateBooksRequest{Requests: requests[:2]})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %v", err)
+	}
+	if stored := storedBooks(t, bookRepo); stored != 1 {
+		t.Fatalf("Expected no books to be created, have %d", stored)
+	}
+
+	// Partial: each item reports its own outcome.
+	response, err := service.BatchCreateBooks(ctx, &v1.BatchCreateBooksRequest{Requests: requests, AllowPartial: true})
+	if err != nil {
+		t.Fatalf("BatchCreateBooks failed: %v", err)
+	}
+	if len(response.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(response.Results))
+	}
+	if r := response.Results[0]; r.Code != int32(codes.OK) || r.Book.GetTitle() != "New" {
+		t.Errorf("Expected first item to be created, got %v", r)
+	}
+	if r := response.Results[1]; r.Code != int32(codes.AlreadyExists) || !strings.Contains(r.Message, repositorytest.ISBN(1)) {
+		t.Errorf("Expected AlreadyExists for second item, got %v", r)
+	}
+	if r := response.Results[2]; r.Code != int32(codes.InvalidArgument) || r.Book != nil {
+		t.Errorf("Expected InvalidArgument for third item, got %v", r)
+	}
+	if _, err := bookRepo.GetBookByID(ctx, existing.Id); err != nil || storedBooks(t, bookRepo) != 2 {
+		t.Errorf("Expected 2 books after partial batch, have %d", storedBooks(t, bookRepo))
+	}
+}
+
+func TestLibraryServiceServerImpl_BatchGetAndDeleteBooks(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	var ids []string
+	for i := 1; i <= 2; i++ {
+		book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)})
+		if err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+		ids = append(ids, book.Id)
+	}
+	withMissing := []string{ids[1], "missing-id", ids[0]}
+
+	_, err := service.BatchGetBooks(ctx, &v1.BatchGetBooksRequest{Ids: withMissing})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	getResponse, err := service.BatchGetBooks(ctx, &v1.BatchGetBooksRequest{Ids: withMissing, AllowPartial: true})
+	if err != nil {
+		t.Fatalf("BatchGetBooks failed: %v", err)
+	}
+	if got := getResponse.Results; got[0].Book.GetId() != ids[1] || got[1].Code != int32(codes.NotFound) || got[2].Book.GetId() != ids[0] {
+		t.Errorf("Unexpected results in request order: %v", got)
+	}
+
+	_, err = service.BatchDeleteBooks(ctx, &v1.BatchDeleteBooksRequest{Ids: withMissing})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if live, _ := bookRepo.CountBooks(ctx, repository.ListBooksParams{}); live != 2 {
+		t.Fatalf("Expected all-or-nothing delete to keep both books, have %d", live)
+	}
+
+	deleteResponse, err := service.BatchDeleteBooks(ctx, &v1.BatchDeleteBooksRequest{Ids: withMissing, AllowPartial: true})
+	if err != nil {
+		t.Fatalf("BatchDeleteBooks failed: %v", err)
+	}
+	if got := deleteResponse.Results; got[0].Code != int32(codes.OK) || got[1].Code != int32(codes.NotFound) || got[2].Code != int32(codes.OK) {
+		t.Errorf("Unexpected delete results: %v", got)
+	}
+	if live, _ := bookRepo.CountBooks(ctx, repository.ListBooksParams{}); live != 0 {
+		t.Errorf("Expected both books to be deleted, have %d", live)
+	}
+
+	_, err = service.BatchGetBooks(ctx, &v1.BatchGetBooksRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty batch, got %v", err)
+	}
+}
+
+// fakeBookStream captures books sent on a server stream.
+type fakeBookStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	books  []*v1.Book
+	onSend func()
+}
+
+func (f *fakeBookStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeBookStream) Send(book *v1.Book) error {
+	f.books = append(f.books, book)
+	if f.onSend != nil {
+		f.onSend()
+	}
+	return nil
+}
+
+func TestLibraryServiceServerImpl_StreamBooks(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		req := &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: int32(i), Isbn: repositorytest.ISBN(i)}
+		if _, err := service.CreateBook(ctx, req); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	stream := &fakeBookStream{ctx: ctx}
+	err := service.StreamBooks(&v1.StreamBooksRequest{Filter: "edition >= 2", OrderBy: "edition desc"}, stream)
+	if err != nil {
+		t.Fatalf("StreamBooks failed: %v", err)
+	}
+	if len(stream.books) != 4 || stream.books[0].Edition != 5 || stream.books[3].Edition != 2 {
+		t.Errorf("Expected editions 5 down to 2, got %v", stream.books)
+	}
+
+	err = service.StreamBooks(&v1.StreamBooksRequest{Filter: "edition >="}, &fakeBookStream{ctx: ctx})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_StreamBooks_Cancelled(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+
+	for i := 1; i <= 5; i++ {
+		req := &v1.CreateBookRequest{Title: fmt.Sprintf("Book %d", i), Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i)}
+		if _, err := service.CreateBook(context.Background(), req); err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client goes away after receiving the second book.
+	stream := &fakeBookStream{ctx: ctx}
+	stream.onSend = func() {
+		if len(stream.books) == 2 {
+			cancel()
+		}
+	}
+
+	err := service.StreamBooks(&v1.StreamBooksRequest{}, stream)
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+	if len(stream.books) != 2 {
+		t.Errorf("Expected streaming to stop after 2 books, got %d", len(stream.books))
+	}
+}
+
+// fakeEventStream captures events sent on a WatchBooks stream.
+type fakeEventStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	events []*v1.BookEvent
+	onSend func()
+}
+
+func (f *fakeEventStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeEventStream) Send(event *v1.BookEvent) error {
+	f.events = append(f.events, event)
+	if f.onSend != nil {
+		f.onSend()
+	}
+	return nil
+}
+
+func TestLibraryServiceServerImpl_WatchBooks(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo, WithWatchBooks())
+	ctx := context.Background()
+
+	created, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Book", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	if _, err := service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: created.Id, Title: "Renamed"}); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if _, err := service.DeleteBook(ctx, &v1.DeleteBookRequest{Id: created.Id}); err != nil {
+		t.Fatalf("DeleteBook failed: %v", err)
+	}
+
+	// Replay the whole history, then hang up.
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream := &fakeEventStream{ctx: watchCtx}
+	stream.onSend = func() {
+		if len(stream.events) == 3 {
+			cancel()
+		}
+	}
+	err = service.WatchBooks(&v1.WatchBooksRequest{ResumeCursor: service.changes.cursor(0)}, stream)
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+
+	wantTypes := []v1.BookEvent_Type{v1.BookEvent_CREATED, v1.BookEvent_UPDATED, v1.BookEvent_DELETED}
+	if len(stream.events) != len(wantTypes) {
+		t.Fatalf("Expected %d events, got %d", len(wantTypes), len(stream.events))
+	}
+	for i, event := range stream.events {
+		if event.Type != wantTypes[i] || event.Book.Id != created.Id {
+			t.Errorf("Event %d: expected %v of %s, got %v of %s", i, wantTypes[i], created.Id, event.Type, event.Book.Id)
+		}
+	}
+	if stream.events[1].Book.Title != "Renamed" {
+		t.Errorf("Expected the update event to carry the new title, got %q", stream.events[1].Book.Title)
+	}
+
+	// Resuming from the second event replays only the delete, then picks up
+	// live writes.
+	watchCtx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	resumed := &fakeEventStream{ctx: watchCtx}
+	resumed.onSend = func() {
+		switch len(resumed.events) {
+		case 1:
+			if _, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Live", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(2)}); err != nil {
+				t.Errorf("CreateBook failed: %v", err)
+			}
+		case 2:
+			cancel()
+		}
+	}
+	err = service.WatchBooks(&v1.WatchBooksRequest{ResumeCursor: stream.events[1].Cursor}, resumed)
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+	if len(resumed.events) != 2 || resumed.events[0].Type != v1.BookEvent_DELETED || resumed.events[1].Book.Title != "Live" {
+		t.Errorf("Expected the delete followed by the live create, got %v", resumed.events)
+	}
+}
+
+// lateRepository pauses after each update, as if the commit had been slow to
+// return, to widen the gap between a write and the event it publishes.
+type lateRepository struct {
+	repository.BookRepository
+}
+
+func (r lateRepository) UpdateBook(ctx context.Context, book *domain.Book, fields []string, version int64) (*domain.Book, error) {
+	updated, err := r.BookRepository.UpdateBook(ctx, book, fields, version)
+	time.Sleep(time.Duration(rand.N(200)) * time.Microsecond)
+	return updated, err
+}
+
+func TestLibraryServiceServerImpl_WatchBooks_ConcurrentUpdates(t *testing.T) {
+	service := New(lateRepository{memory.NewBookRepository()}, WithWatchBooks())
+	ctx := context.Background()
+
+	const books, writers, updates = 4, 8, 25
+	ids := make([]string, books)
+	for i := range ids {
+		book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Book", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(i + 1)})
+		if err != nil {
+			t.Fatalf("CreateBook failed: %v", err)
+		}
+		ids[i] = book.Id
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				req := &v1.UpdateBookRequest{Id: ids[(w+i)%books], Title: fmt.Sprintf("Writer %d update %d", w, i)}
+				if _, err := service.UpdateBook(ctx, req); err != nil {
+					t.Errorf("UpdateBook failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := &fakeEventStream{ctx: watchCtx}
+	stream.onSend = func() {
+		if len(stream.events) == books+writers*updates {
+			cancel()
+		}
+	}
+	if err := service.WatchBooks(&v1.WatchBooksRequest{ResumeCursor: service.changes.cursor(0)}, stream); status.Code(err) != codes.Canceled {
+		t.Fatalf("Expected Canceled, got %v", err)
+	}
+
+	last := make(map[string]int64)
+	for _, event := range stream.events {
+		version, err := domain.ParseETag(event.Book.Etag)
+		if err != nil {
+			t.Fatalf("ParseETag failed: %v", err)
+		}
+		if version <= last[event.Book.Id] {
+			t.Errorf("Book %s: version %d arrived after version %d", event.Book.Id, version, last[event.Book.Id])
+		}
+		last[event.Book.Id] = version
+	}
+}
+
+func TestLibraryServiceServerImpl_WatchBooks_InvalidCursor(t *testing.T) {
+	service := New(memory.NewBookRepository(), WithWatchBooks())
+	ctx := context.Background()
+
+	err := service.WatchBooks(&v1.WatchBooksRequest{ResumeCursor: "not a cursor"}, &fakeEventStream{ctx: ctx})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+
+	// A cursor handed out by another server process cannot be resumed.
+	other := New(memory.NewBookRepository(), WithWatchBooks())
+	err = service.WatchBooks(&v1.WatchBooksRequest{ResumeCursor: other.changes.cursor(0)}, &fakeEventStream{ctx: ctx})
+	if status.Code(err) != codes.OutOfRange {
+		t.Errorf("Expected OutOfRange, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_CloseWatches(t *testing.T) {
+	service := New(memory.NewBookRepository(), WithWatchBooks())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- service.WatchBooks(&v1.WatchBooksRequest{}, &fakeEventStream{ctx: context.Background()})
+	}()
+	service.CloseWatches()
+
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("Expected Unavailable, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchBooks did not return after CloseWatches")
+	}
+
+	// Watches started afterwards end right away too.
+	err := service.WatchBooks(&v1.WatchBooksRequest{}, &fakeEventStream{ctx: context.Background()})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for a new watch, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_WatchBooks_Disabled(t *testing.T) {
+	service := New(memory.NewBookRepository())
+	ctx := context.Background()
+
+	if _, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Book", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)}); err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	err := service.WatchBooks(&v1.WatchBooksRequest{}, &fakeEventStream{ctx: ctx})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_BookRevisions(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := repository.WithActor(context.Background(), "alice")
+
+	book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	bob := repository.WithActor(context.Background(), "bob")
+	if _, err := service.UpdateBook(bob, &v1.UpdateBookRequest{Id: book.Id, Title: "Typo", Edition: 2}); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+	if _, err := service.UpdateBook(bob, &v1.UpdateBookRequest{Id: book.Id, Author: "Someone"}); err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	// Two pages of two and one revision; the second page still diffs
+	// against the last revision of the first.
+	first, err := service.ListBookRevisions(ctx, &v1.ListBookRevisionsRequest{BookId: book.Id, PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListBookRevisions failed: %v", err)
+	}
+	if len(first.Revisions) != 2 || first.NextPageToken == "" {
+		t.Fatalf("Expected 2 revisions and a next page, got %d, %q", len(first.Revisions), first.NextPageToken)
+	}
+	created, updated := first.Revisions[0], first.Revisions[1]
+	if created.Action != v1.BookRevision_CREATE || created.Actor != "alice" || len(created.Changes) != 4 {
+		t.Errorf("Unexpected first revision: %v", created)
+	}
+	if updated.Action != v1.BookRevision_UPDATE || updated.Actor != "bob" || updated.Revision != 2 {
+		t.Errorf("Unexpected second revision: %v", updated)
+	}
+	wantChanges := []*v1.FieldChange{
+		{Field: "title", OldValue: "Title", NewValue: "Typo"},
+		{Field: "edition", OldValue: "1", NewValue: "2"},
+	}
+	if len(updated.Changes) != len(wantChanges) {
+		t.Fatalf("Expected changes %v, got %v", wantChanges, updated.Changes)
+	}
+	for i, change := range updated.Changes {
+		if change.Field != wantChanges[i].Field || change.OldValue != wantChanges[i].OldValue || change.NewValue != wantChanges[i].NewValue {
+			t.Errorf("Change %d: expected %v, got %v", i, wantChanges[i], change)
+		}
+	}
+
+	second, err := service.ListBookRevisions(ctx, &v1.ListBookRevisionsRequest{BookId: book.Id, PageSize: 2, PageToken: first.NextPageToken})
+	if err != nil {
+		t.Fatalf("ListBookRevisions second page failed: %v", err)
+	}
+	if len(second.Revisions) != 1 || second.NextPageToken != "" {
+		t.Fatalf("Expected a final page of 1 revision, got %d, %q", len(second.Revisions), second.NextPageToken)
+	}
+	if changes := second.Revisions[0].Changes; len(changes) != 1 || changes[0].Field != "author" || changes[0].OldValue != "Author" {
+		t.Errorf("Expected only the author to change, got %v", changes)
+	}
+
+	_, err = service.ListBookRevisions(ctx, &v1.ListBookRevisionsRequest{BookId: "other", PageToken: first.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a token of another book, got %v", err)
+	}
+	_, err = service.ListBookRevisions(ctx, &v1.ListBookRevisionsRequest{BookId: "non-existent-id"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestLibraryServiceServerImpl_RestoreBookRevision(t *testing.T) {
+	bookRepo := memory.NewBookRepository()
+	service := New(bookRepo)
+	ctx := context.Background()
+
+	book, err := service.CreateBook(ctx, &v1.CreateBookRequest{Title: "Title", Author: "Author", Edition: 1, Isbn: repositorytest.ISBN(1)})
+	if err != nil {
+		t.Fatalf("CreateBook failed: %v", err)
+	}
+	updated, err := service.UpdateBook(ctx, &v1.UpdateBookRequest{Id: book.Id, Title: "Wrong", Author: "Wrong"})
+	if err != nil {
+		t.Fatalf("UpdateBook failed: %v", err)
+	}
+
+	_, err = service.RestoreBookRevision(ctx, &v1.RestoreBookRevisionRequest{BookId: book.Id, Revision: 1, Etag: book.Etag})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted for a stale etag, got %v", err)
+	}
+	_, err = service.RestoreBookRevision(ctx, &v1.RestoreBookRevisionRequest{BookId: book.Id, Revision: 9})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown revision, got %v", err)
+	}
+	_, err = service.RestoreBookRevision(ctx, &v1.RestoreBookRevisionRequest{BookId: book.Id})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a revision, got %v", err)
+	}
+
+	restored, err := service.RestoreBookRevision(ctx, &v1.RestoreBookRevisionRequest{BookId: book.Id, Revision: 1, Etag: updated.Etag})
+	if err != nil {
+		t.Fatalf("RestoreBookRevision failed: %v", err)
+	}
+	if restored.Title != "Title" || restored.Author != "Author" || restored.Etag != "3" {
+		t.Errorf("Expected revision 1 restored as version 3, got %v", restored)
+	}
+
+	history, err := service.ListBookRevisions(ctx, &v1.ListBookRevisionsRequest{BookId: book.Id})
+	if err != nil {
+		t.Fatalf("ListBookRevisions failed: %v", err)
+	}
+	last := history.Revisions[len(history.Revisions)-1]
+	if last.Action != v1.BookRevision_RESTORE || last.Actor != repository.SystemActor || len(last.Changes) != 2 {
+		t.Errorf("Expected a restore revision undoing both fields, got %v", last)
 	}
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+)
+
+const defaultDeletedRetention = 30 * 24 * time.Hour
+
+// purgeBatchSize is how many books PurgeExpiredBooks removes per
+// transaction, so that a long backlog does not turn into one transaction
+// large enough to hit CockroachDB's retry and size limits.
+const purgeBatchSize = 500
+
+// PurgeExpiredBooks permanently removes the books that were soft-deleted
+// longer ago than the retention period, in batches of purgeBatchSize, and
+// returns how many it removed. After an error the count covers the batches
+// that were committed.
+func (s *LibraryServiceServerImpl) PurgeExpiredBooks(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.deletedRetention)
+	total := 0
+	for {
+		n, err := s.purgeBatch(ctx, before)
+		total += n
+		if err != nil || n < s.purgeBatchSize {
+			return total, err
+		}
+	}
+}
+
+// purgeBatch removes one batch of the books deleted before the given time.
+func (s *LibraryServiceServerImpl) purgeBatch(ctx context.Context, before time.Time) (int, error) {
+	defer s.lockWrites()()
+	purged, err := s.repo.PurgeDeletedBooks(ctx, before, s.purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range purged {
+		s.publishChanges(v1.BookEvent_PURGED, &domain.Book{ID: id})
+	}
+	return len(purged), nil
+}
+
+// RunPurger calls PurgeExpiredBooks every interval until ctx is done.
+func (s *LibraryServiceServerImpl) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.PurgeExpiredBooks(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Error("Failed to purge deleted books", "purged", n, "error", err)
+		case n > 0:
+			slog.Info("Purged deleted books", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListBookRevisions pages through a book's history, oldest first. Diffs are
+// computed against the preceding revision, so every page after the first
+// also reads the last revision of the page before it.
+func (s *LibraryServiceServerImpl) ListBookRevisions(ctx context.Context, req *v1.ListBookRevisionsRequest) (*v1.ListBookRevisionsResponse, error) {
+	pageSize, from, err := s.validateListBookRevisionsRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether another page follows.
+	limit := pageSize + 1
+	if from > 0 {
+		limit++
+	}
+	revisions, err := s.repo.ListBookRevisions(ctx, req.BookId, from, limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list book revisions: %v", err)
+	}
+
+	var previous *domain.BookRevision
+	if from > 0 {
+		if len(revisions) == 0 || revisions[0].Revision != from {
+			var v fieldViolations
+			v.add("page_token", "must be a token returned by a previous call for the same book")
+			return nil, v.err()
+		}
+		previous, revisions = revisions[0], revisions[1:]
+	} else if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no history for book %s", req.BookId)
+	}
+
+	response := &v1.ListBookRevisionsResponse{}
+	if len(revisions) > pageSize {
+		revisions = revisions[:pageSize]
+		token, err := s.encodeRevisionToken(req.BookId, revisions[len(revisions)-1].Revision)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create page token: %v", err)
+		}
+		response.NextPageToken = token
+	}
+
+	for _, rev := range revisions {
+		response.Revisions = append(response.Revisions, domain.RevisionToDto(rev, previous))
+		previous = rev
+	}
+	return response, nil
+}
+
+// encodeRevisionToken issues a ListBookRevisions page token, scoped to one
+// book, that resumes after revision.
+func (s *LibraryServiceServerImpl) encodeRevisionToken(bookID string, revision int64) (string, error) {
+	return s.pageTokens.encode(pageToken{Query: "revisions/" + bookID, LastID: strconv.FormatInt(revision, 10)})
+}
+
+func (s *LibraryServiceServerImpl) decodeRevisionToken(encoded, bookID string) (int64, error) {
+	token, err := s.pageTokens.decode(encoded)
+	if err != nil {
+		return 0, err
+	}
+	if token.Query != "revisions/"+bookID {
+		return 0, errInvalidPageToken
+	}
+	return strconv.ParseInt(token.LastID, 10, 64)
+}
+
+func (s *LibraryServiceServerImpl) RestoreBookRevision(ctx context.Context, req *v1.RestoreBookRevisionRequest) (*v1.Book, error) {
+	expectedVersion, err := validateRestoreBookRevisionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.ListBookRevisions(ctx, req.BookId, req.Revision, 1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get book revision: %v", err)
+	}
+	if len(revisions) == 0 || revisions[0].Revision != req.Revision {
+		return nil, status.Errorf(codes.NotFound, "book %s has no revision %d", req.BookId, req.Revision)
+	}
+	target := revisions[0]
+
+	defer s.lockWrites()()
+	book, err := s.repo.RestoreBookRevision(ctx, req.BookId, req.Revision, expectedVersion)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book not found: %s", req.BookId)
+		}
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			return nil, status.Errorf(codes.Aborted, "etag mismatch for book %s", req.BookId)
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, alreadyExists(err, &domain.Book{ISBN: target.ISBN, Edition: target.Edition})
+		}
+		return nil, writeError("restore book revision", err)
+	}
+
+	s.publishChanges(v1.BookEvent_UPDATED, book)
+
+	return domain.BookToDto(book), nil
+}
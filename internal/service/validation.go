@@ -0,0 +1,297 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	"github.com/igoventura/go-grpc-library-service/internal/filter"
+	"github.com/igoventura/go-grpc-library-service/internal/repository"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxTextLength = 512
+	maxBatchSize  = 1000
+	// maxQueryLength caps filter and order_by, in bytes, before they are
+	// parsed.
+	maxQueryLength = 4096
+)
+
+// fieldViolations collects every problem with a request so the client gets
+// them all at once instead of fixing one field per round trip.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+func (v *fieldViolations) add(field, format string, args ...any) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns an InvalidArgument status carrying a BadRequest detail, or nil
+// when no violations were recorded.
+func (v fieldViolations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(v))
+	for i, violation := range v {
+		descriptions[i] = violation.Field + ": " + violation.Description
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, "; "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (v *fieldViolations) checkID(field, id string) {
+	if strings.TrimSpace(id) == "" {
+		v.add(field, "must not be empty")
+	}
+}
+
+func (v *fieldViolations) checkText(field, value string) {
+	switch {
+	case strings.TrimSpace(value) == "":
+		v.add(field, "must not be empty")
+	case utf8.RuneCountInString(value) > maxTextLength:
+		v.add(field, "must be at most %d characters", maxTextLength)
+	}
+}
+
+func (v *fieldViolations) checkEdition(field string, edition int32) {
+	if edition < 1 {
+		v.add(field, "must be a positive number, got %d", edition)
+	}
+}
+
+func (v *fieldViolations) checkISBN(field, isbn string) {
+	if _, err := domain.ParseISBN(isbn); err != nil {
+		v.add(field, "must be an ISBN-10 or ISBN-13 with a valid check digit, got %q", isbn)
+	}
+}
+
+// checkPageSize validates a page size and applies the default and maximum.
+func (v *fieldViolations) checkPageSize(field string, size int32) int {
+	switch {
+	case size < 0:
+		v.add(field, "must not be negative, got %d", size)
+		return 0
+	case size == 0:
+		return defaultPageSize
+	case size > maxPageSize:
+		return maxPageSize
+	}
+	return int(size)
+}
+
+// checkETag validates an optional etag and returns the version it refers to.
+func (v *fieldViolations) checkETag(field, etag string) int64 {
+	if etag == "" {
+		return 0
+	}
+	version, err := domain.ParseETag(etag)
+	if err != nil {
+		v.add(field, "%v", err)
+	}
+	return version
+}
+
+func validateCreateBookRequest(req *v1.CreateBookRequest) error {
+	var v fieldViolations
+	v.checkCreateBookRequest("", req)
+	return v.err()
+}
+
+// checkCreateBookRequest validates req, prefixing field names so the same
+// rules can report on items nested in batch requests.
+func (v *fieldViolations) checkCreateBookRequest(prefix string, req *v1.CreateBookRequest) {
+	v.checkText(prefix+"title", req.Title)
+	v.checkText(prefix+"author", req.Author)
+	v.checkEdition(prefix+"edition", req.Edition)
+	v.checkISBN(prefix+"isbn", req.Isbn)
+}
+
+func (v *fieldViolations) checkBatchSize(field string, size int) {
+	switch {
+	case size == 0:
+		v.add(field, "must not be empty")
+	case size > maxBatchSize:
+		v.add(field, "must contain at most %d items, got %d", maxBatchSize, size)
+	}
+}
+
+// validateBatchCreateBooksRequest checks the batch as a whole. In
+// all-or-nothing mode every item is validated too; in partial mode items are
+// validated one by one so that each gets its own result.
+func validateBatchCreateBooksRequest(req *v1.BatchCreateBooksRequest) error {
+	var v fieldViolations
+	v.checkBatchSize("requests", len(req.Requests))
+	if !req.AllowPartial {
+		for i, item := range req.Requests {
+			v.checkCreateBookRequest(fmt.Sprintf("requests[%d].", i), item)
+		}
+	}
+	return v.err()
+}
+
+func validateBatchIDs(ids []string) error {
+	var v fieldViolations
+	v.checkBatchSize("ids", len(ids))
+	for i, id := range ids {
+		v.checkID(fmt.Sprintf("ids[%d]", i), id)
+	}
+	return v.err()
+}
+
+func validateGetBookRequest(req *v1.GetBookRequest) error {
+	var v fieldViolations
+	v.checkID("id", req.Id)
+	return v.err()
+}
+
+func validateGetBookByIsbnRequest(req *v1.GetBookByIsbnRequest) error {
+	var v fieldViolations
+	v.checkISBN("isbn", req.Isbn)
+	return v.err()
+}
+
+// validateUpdateBookRequest checks the id, etag, update_mask and every field
+// the mask selects, returning the resolved fields and expected version.
+func validateUpdateBookRequest(req *v1.UpdateBookRequest) ([]string, int64, error) {
+	var v fieldViolations
+	v.checkID("id", req.Id)
+	version := v.checkETag("etag", req.Etag)
+
+	fields, err := updateFields(req)
+	if err != nil {
+		v.add("update_mask", "%v", err)
+	}
+
+	for _, field := range fields {
+		switch field {
+		case repository.FieldTitle:
+			v.checkText("title", req.Title)
+		case repository.FieldAuthor:
+			v.checkText("author", req.Author)
+		case repository.FieldEdition:
+			v.checkEdition("edition", req.Edition)
+		case repository.FieldISBN:
+			v.checkISBN("isbn", req.Isbn)
+		}
+	}
+
+	return fields, version, v.err()
+}
+
+func validateDeleteBookRequest(req *v1.DeleteBookRequest) (int64, error) {
+	var v fieldViolations
+	v.checkID("id", req.Id)
+	version := v.checkETag("etag", req.Etag)
+	return version, v.err()
+}
+
+func validateUndeleteBookRequest(req *v1.UndeleteBookRequest) (int64, error) {
+	var v fieldViolations
+	v.checkID("id", req.Id)
+	version := v.checkETag("etag", req.Etag)
+	return version, v.err()
+}
+
+func validatePurgeBookRequest(req *v1.PurgeBookRequest) (int64, error) {
+	var v fieldViolations
+	v.checkID("id", req.Id)
+	version := v.checkETag("etag", req.Etag)
+	return version, v.err()
+}
+
+// listQuery is a validated ListBooks request.
+type listQuery struct {
+	params      repository.ListBooksParams
+	pageSize    int
+	fingerprint string
+}
+
+func (s *LibraryServiceServerImpl) validateListBooksRequest(req *v1.ListBooksRequest) (listQuery, error) {
+	var v fieldViolations
+
+	query := listQuery{
+		pageSize:    v.checkPageSize("page_size", req.PageSize),
+		fingerprint: queryFingerprint(req.Filter, req.OrderBy, req.ShowDeleted),
+	}
+	// Ask for one extra row so we know whether another page follows.
+	query.params.PageSize = query.pageSize + 1
+
+	query.params.Filter, query.params.OrderBy = v.checkQuery(req.Filter, req.OrderBy)
+	query.params.ShowDeleted = req.ShowDeleted
+
+	if req.PageToken != "" && len(v) == 0 {
+		var err error
+		if query.params.After, err = s.decodeCursor(req.PageToken, query.fingerprint, query.params.OrderBy); err != nil {
+			v.add("page_token", "must be a token returned by a previous call with the same filter and order_by")
+		}
+	}
+
+	return query, v.err()
+}
+
+func (s *LibraryServiceServerImpl) validateListBookRevisionsRequest(req *v1.ListBookRevisionsRequest) (int, int64, error) {
+	var v fieldViolations
+	v.checkID("book_id", req.BookId)
+	pageSize := v.checkPageSize("page_size", req.PageSize)
+
+	var from int64
+	if req.PageToken != "" {
+		var err error
+		if from, err = s.decodeRevisionToken(req.PageToken, req.BookId); err != nil {
+			v.add("page_token", "must be a token returned by a previous call for the same book")
+		}
+	}
+
+	return pageSize, from, v.err()
+}
+
+func validateRestoreBookRevisionRequest(req *v1.RestoreBookRevisionRequest) (int64, error) {
+	var v fieldViolations
+	v.checkID("book_id", req.BookId)
+	if req.Revision < 1 {
+		v.add("revision", "must be a positive number, got %d", req.Revision)
+	}
+	version := v.checkETag("etag", req.Etag)
+	return version, v.err()
+}
+
+func validateStreamBooksRequest(req *v1.StreamBooksRequest) (repository.ListBooksParams, error) {
+	var v fieldViolations
+	params := repository.ListBooksParams{ShowDeleted: req.ShowDeleted}
+	params.Filter, params.OrderBy = v.checkQuery(req.Filter, req.OrderBy)
+	return params, v.err()
+}
+
+// checkQuery parses the filter and order_by shared by ListBooks and
+// StreamBooks.
+func (v *fieldViolations) checkQuery(filterExpr, orderBy string) (filter.Expr, []filter.Order) {
+	var expr filter.Expr
+	var orders []filter.Order
+	var err error
+
+	if len(filterExpr) > maxQueryLength {
+		v.add("filter", "must be at most %d bytes", maxQueryLength)
+	} else if expr, err = filter.Parse(filterExpr, repository.BookFilterSchema); err != nil {
+		v.add("filter", "%v", err)
+	}
+	if len(orderBy) > maxQueryLength {
+		v.add("order_by", "must be at most %d bytes", maxQueryLength)
+	} else if orders, err = filter.ParseOrderBy(orderBy, repository.BookFilterSchema); err != nil {
+		v.add("order_by", "%v", err)
+	}
+	return expr, orders
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoventura/go-grpc-library-service/internal/domain"
+	v1 "github.com/igoventura/go-grpc-library-service/pkg/pb/library/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchHistorySize is how many recent events are kept for clients resuming
+// after a disconnect.
+const watchHistorySize = 4096
+
+var (
+	errMalformedCursor = errors.New("malformed cursor")
+	errExpiredCursor   = errors.New("cursor is no longer available")
+)
+
+type bookEvent struct {
+	seq  uint64
+	typ  v1.BookEvent_Type
+	book *domain.Book
+	time time.Time
+}
+
+// changeFeed is an in-memory log of the writes made through this server.
+// Writes handled by other replicas never reach it, which is why WatchBooks
+// has to be enabled explicitly. Events carry a sequence number scoped to an
+// epoch that changes on every restart, so cursors from an earlier process are
+// recognised as expired rather than silently skipping events.
+type changeFeed struct {
+	// writes is held from before a write reaches the repository until its
+	// events are published, so that events come out in commit order and a
+	// watcher never sees an older version of a book after a newer one.
+	writes sync.Mutex
+
+	mu          sync.Mutex
+	epoch       string
+	lastSeq     uint64
+	history     []bookEvent
+	subscribers map[chan struct{}]struct{}
+	// closed is closed by close to end every watch.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newChangeFeed() *changeFeed {
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		panic(err)
+	}
+	return &changeFeed{
+		epoch:       hex.EncodeToString(epoch),
+		subscribers: make(map[chan struct{}]struct{}),
+		closed:      make(chan struct{}),
+	}
+}
+
+// close ends every current and future watch.
+func (f *changeFeed) close() {
+	f.closeOnce.Do(func() { close(f.closed) })
+}
+
+// publish appends an event and wakes up every watcher. It never blocks on
+// slow watchers.
+func (f *changeFeed) publish(typ v1.BookEvent_Type, book *domain.Book) {
+	copied := *book
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastSeq++
+	f.history = append(f.history, bookEvent{seq: f.lastSeq, typ: typ, book: &copied, time: time.Now()})
+	if len(f.history) > watchHistorySize {
+		f.history = f.history[len(f.history)-watchHistorySize:]
+	}
+
+	for notify := range f.subscribers {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *changeFeed) subscribe() (<-chan struct{}, func()) {
+	notify := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	f.subscribers[notify] = struct{}{}
+	f.mu.Unlock()
+
+	return notify, func() {
+		f.mu.Lock()
+		delete(f.subscribers, notify)
+		f.mu.Unlock()
+	}
+}
+
+// position returns the sequence number of the latest event.
+func (f *changeFeed) position() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastSeq
+}
+
+// since returns the events after seq, or errExpiredCursor when some of them
+// have already been dropped from the history.
+func (f *changeFeed) since(seq uint64) ([]bookEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if seq > f.lastSeq {
+		return nil, errExpiredCursor
+	}
+	if len(f.history) == 0 || seq == f.lastSeq {
+		return nil, nil
+	}
+
+	oldest := f.history[0].seq
+	if seq+1 < oldest {
+		return nil, errExpiredCursor
+	}
+	return append([]bookEvent(nil), f.history[seq+1-oldest:]...), nil
+}
+
+func (f *changeFeed) cursor(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(f.epoch + ":" + strconv.FormatUint(seq, 10)))
+}
+
+func (f *changeFeed) parseCursor(cursor string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errMalformedCursor
+	}
+	epoch, seq, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return 0, errMalformedCursor
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, errMalformedCursor
+	}
+	if epoch != f.epoch {
+		return 0, errExpiredCursor
+	}
+	return n, nil
+}
+
+// WatchBooks streams catalog changes. Events are replayed from the resume
+// cursor when one is given and then pushed live until the client goes away.
+func (s *LibraryServiceServerImpl) WatchBooks(req *v1.WatchBooksRequest, stream grpc.ServerStreamingServer[v1.BookEvent]) error {
+	if s.changes == nil {
+		return status.Error(codes.Unimplemented, "WatchBooks is disabled on this server; it only sees its own writes, so it is only served by single-replica deployments")
+	}
+	ctx := stream.Context()
+
+	// Subscribe before reading the position so no event can slip in between.
+	notify, unsubscribe := s.changes.subscribe()
+	defer unsubscribe()
+
+	seq := s.changes.position()
+	if req.ResumeCursor != "" {
+		var err error
+		if seq, err = s.changes.parseCursor(req.ResumeCursor); err != nil {
+			return watchCursorError(err)
+		}
+	}
+
+	for {
+		events, err := s.changes.since(seq)
+		if err != nil {
+			return watchCursorError(err)
+		}
+
+		for _, event := range events {
+			book := &v1.Book{Id: event.book.ID}
+			if event.typ != v1.BookEvent_DELETED && event.typ != v1.BookEvent_PURGED {
+				book = domain.BookToDto(event.book)
+			}
+			err := stream.Send(&v1.BookEvent{
+				Type:      event.typ,
+				Book:      book,
+				Cursor:    s.changes.cursor(event.seq),
+				EventTime: timestamppb.New(event.time),
+			})
+			if err != nil {
+				return err
+			}
+			seq = event.seq
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.changes.closed:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-notify:
+		}
+	}
+}
+
+// CloseWatches ends every WatchBooks stream with codes.Unavailable and
+// refuses new ones. Watch streams never end on their own, so this is called
+// when shutting down to let a graceful stop finish.
+func (s *LibraryServiceServerImpl) CloseWatches() {
+	if s.changes != nil {
+		s.changes.close()
+	}
+}
+
+func watchCursorError(err error) error {
+	if errors.Is(err, errExpiredCursor) {
+		return status.Errorf(codes.OutOfRange, "resume_cursor %v; list the books again and watch from the current position", err)
+	}
+	var v fieldViolations
+	v.add("resume_cursor", "%v", err)
+	return v.err()
+}
+
+// lockWrites serializes writes while WatchBooks is enabled, so that each
+// write commits and publishes its events before the next one starts. It
+// returns the matching unlock and does nothing when there is no feed.
+func (s *LibraryServiceServerImpl) lockWrites() func() {
+	if s.changes == nil {
+		return func() {}
+	}
+	s.changes.writes.Lock()
+	return s.changes.writes.Unlock
+}
+
+// publishChanges records a successful write in the change feed, if there is
+// one. Callers hold lockWrites across the write and the call.
+func (s *LibraryServiceServerImpl) publishChanges(typ v1.BookEvent_Type, books ...*domain.Book) {
+	if s.changes == nil {
+		return
+	}
+	for _, book := range books {
+		s.changes.publish(typ, book)
+	}
+}
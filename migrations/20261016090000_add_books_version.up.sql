@@ -0,0 +1 @@
+ALTER TABLE books ADD COLUMN version INT8 NOT NULL DEFAULT 1;
\ No newline at end of file
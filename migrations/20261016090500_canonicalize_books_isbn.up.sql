@@ -0,0 +1,43 @@
+-- Rewrite ISBNs stored with hyphens or spaces, or as ISBN-10, to the
+-- canonical ISBN-13 the service stores and looks books up by. Values that are
+-- not valid ISBNs are left as they are. The checksums are computed inside
+-- CASE so that digits are only cast once the pattern is known to match.
+WITH stripped AS (
+    SELECT id, upper(replace(replace(isbn, '-', ''), ' ', '')) AS digits FROM books
+),
+isbn10 AS (
+    SELECT id, '978' || substr(digits, 1, 9) AS body
+    FROM stripped
+    WHERE CASE WHEN digits ~ '^[0-9]{9}[0-9X]$' THEN
+        (10 * substr(digits, 1, 1)::INT + 9 * substr(digits, 2, 1)::INT
+       + 8 * substr(digits, 3, 1)::INT + 7 * substr(digits, 4, 1)::INT
+       + 6 * substr(digits, 5, 1)::INT + 5 * substr(digits, 6, 1)::INT
+       + 4 * substr(digits, 7, 1)::INT + 3 * substr(digits, 8, 1)::INT
+       + 2 * substr(digits, 9, 1)::INT
+       + CASE WHEN substr(digits, 10, 1) = 'X' THEN 10 ELSE substr(digits, 10, 1)::INT END) % 11 = 0
+    ELSE false END
+),
+canonical AS (
+    SELECT id, body || ((10 - (substr(body, 1, 1)::INT + 3 * substr(body, 2, 1)::INT
+       + substr(body, 3, 1)::INT + 3 * substr(body, 4, 1)::INT
+       + substr(body, 5, 1)::INT + 3 * substr(body, 6, 1)::INT
+       + substr(body, 7, 1)::INT + 3 * substr(body, 8, 1)::INT
+       + substr(body, 9, 1)::INT + 3 * substr(body, 10, 1)::INT
+       + substr(body, 11, 1)::INT + 3 * substr(body, 12, 1)::INT) % 10) % 10)::STRING AS isbn
+    FROM isbn10
+    UNION ALL
+    SELECT id, digits AS isbn
+    FROM stripped
+    WHERE CASE WHEN digits ~ '^97[89][0-9]{10}$' THEN
+        (substr(digits, 1, 1)::INT + 3 * substr(digits, 2, 1)::INT
+       + substr(digits, 3, 1)::INT + 3 * substr(digits, 4, 1)::INT
+       + substr(digits, 5, 1)::INT + 3 * substr(digits, 6, 1)::INT
+       + substr(digits, 7, 1)::INT + 3 * substr(digits, 8, 1)::INT
+       + substr(digits, 9, 1)::INT + 3 * substr(digits, 10, 1)::INT
+       + substr(digits, 11, 1)::INT + 3 * substr(digits, 12, 1)::INT
+       + substr(digits, 13, 1)::INT) % 10 = 0
+    ELSE false END
+)
+UPDATE books SET isbn = canonical.isbn
+FROM canonical
+WHERE books.id = canonical.id AND books.isbn <> canonical.isbn;
@@ -0,0 +1,3 @@
+INSERT INTO books (id, title, author, edition, isbn, version, created_at, updated_at)
+    SELECT id, title, author, edition, isbn, version, created_at, updated_at FROM books_duplicates;
+DROP TABLE books_duplicates;
@@ -0,0 +1,24 @@
+-- Keep the oldest book of each (isbn, edition) and move the others to
+-- books_duplicates, so that the unique index added next can be built. The
+-- moved rows are kept for an operator to merge into the kept book or drop.
+CREATE TABLE books_duplicates (
+    id UUID PRIMARY KEY,
+    kept_id UUID NOT NULL,
+    title STRING NOT NULL,
+    author STRING NOT NULL,
+    edition INT NOT NULL,
+    isbn STRING NOT NULL,
+    version INT8 NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+INSERT INTO books_duplicates (id, kept_id, title, author, edition, isbn, version, created_at, updated_at)
+    SELECT id, kept_id, title, author, edition, isbn, version, created_at, updated_at
+    FROM (
+        SELECT *,
+            row_number() OVER (PARTITION BY isbn, edition ORDER BY created_at, id) AS n,
+            first_value(id) OVER (PARTITION BY isbn, edition ORDER BY created_at, id) AS kept_id
+        FROM books
+    ) AS ranked
+    WHERE n > 1;
+DELETE FROM books WHERE id IN (SELECT id FROM books_duplicates);
@@ -0,0 +1 @@
+DROP INDEX books@books_isbn_edition_key;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Existing duplicates were moved to books_duplicates by the previous migration.
+CREATE UNIQUE INDEX books_isbn_edition_key ON books (isbn, edition);
\ No newline at end of file
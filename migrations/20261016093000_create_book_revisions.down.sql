@@ -0,0 +1 @@
+DROP TABLE book_revisions;
\ No newline at end of file
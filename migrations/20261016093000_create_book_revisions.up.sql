@@ -0,0 +1,14 @@
+CREATE TABLE book_revisions (
+    book_id UUID NOT NULL,
+    revision INT8 NOT NULL,
+    action STRING NOT NULL,
+    actor STRING NOT NULL,
+    title STRING NOT NULL,
+    author STRING NOT NULL,
+    edition INT NOT NULL,
+    isbn STRING NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (book_id, revision)
+);
+INSERT INTO book_revisions (book_id, revision, action, actor, title, author, edition, isbn, created_at)
+    SELECT id, version, 'snapshot', 'system', title, author, edition, isbn, COALESCE(updated_at, now()) FROM books;
\ No newline at end of file
@@ -0,0 +1,26 @@
+// Package migrations embeds the SQL migrations so the server binary can apply
+// them without the files being deployed next to it.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var cockroach embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// Cockroach returns the CockroachDB migrations.
+func Cockroach() fs.FS {
+	return cockroach
+}
+
+// SQLite returns the SQLite migrations.
+func SQLite() fs.FS {
+	// fs.Sub only fails for an invalid path, and "sqlite" is valid.
+	sub, _ := fs.Sub(sqlite, "sqlite")
+	return sub
+}
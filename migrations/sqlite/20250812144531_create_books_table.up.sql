@@ -0,0 +1,9 @@
+CREATE TABLE books (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    edition INTEGER NOT NULL,
+    isbn TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
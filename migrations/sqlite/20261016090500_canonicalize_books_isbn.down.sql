@@ -0,0 +1 @@
+-- The original spelling of the ISBNs is not kept, so there is nothing to undo.
@@ -0,0 +1,41 @@
+-- Rewrite ISBNs stored with hyphens or spaces, or as ISBN-10, to the
+-- canonical ISBN-13 the service stores and looks books up by. Values that are
+-- not valid ISBNs are left as they are. SQLite converts the single-character
+-- substrings to numbers in arithmetic.
+WITH stripped AS (
+    SELECT id, upper(replace(replace(isbn, '-', ''), ' ', '')) AS digits FROM books
+),
+isbn10 AS (
+    SELECT id, '978' || substr(digits, 1, 9) AS body
+    FROM stripped
+    WHERE digits GLOB '[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9X]'
+      AND (10 * substr(digits, 1, 1) + 9 * substr(digits, 2, 1)
+         + 8 * substr(digits, 3, 1) + 7 * substr(digits, 4, 1)
+         + 6 * substr(digits, 5, 1) + 5 * substr(digits, 6, 1)
+         + 4 * substr(digits, 7, 1) + 3 * substr(digits, 8, 1)
+         + 2 * substr(digits, 9, 1)
+         + CASE WHEN substr(digits, 10, 1) = 'X' THEN 10 ELSE substr(digits, 10, 1) END) % 11 = 0
+),
+canonical AS (
+    SELECT id, body || ((10 - (substr(body, 1, 1) + 3 * substr(body, 2, 1)
+         + substr(body, 3, 1) + 3 * substr(body, 4, 1)
+         + substr(body, 5, 1) + 3 * substr(body, 6, 1)
+         + substr(body, 7, 1) + 3 * substr(body, 8, 1)
+         + substr(body, 9, 1) + 3 * substr(body, 10, 1)
+         + substr(body, 11, 1) + 3 * substr(body, 12, 1)) % 10) % 10) AS isbn
+    FROM isbn10
+    UNION ALL
+    SELECT id, digits AS isbn
+    FROM stripped
+    WHERE digits GLOB '97[89][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]'
+      AND (substr(digits, 1, 1) + 3 * substr(digits, 2, 1)
+         + substr(digits, 3, 1) + 3 * substr(digits, 4, 1)
+         + substr(digits, 5, 1) + 3 * substr(digits, 6, 1)
+         + substr(digits, 7, 1) + 3 * substr(digits, 8, 1)
+         + substr(digits, 9, 1) + 3 * substr(digits, 10, 1)
+         + substr(digits, 11, 1) + 3 * substr(digits, 12, 1)
+         + substr(digits, 13, 1)) % 10 = 0
+)
+UPDATE books SET isbn = canonical.isbn
+FROM canonical
+WHERE books.id = canonical.id AND books.isbn <> canonical.isbn;
@@ -0,0 +1,24 @@
+-- Keep the oldest book of each (isbn, edition) and move the others to
+-- books_duplicates, so that the unique index added next can be built. The
+-- moved rows are kept for an operator to merge into the kept book or drop.
+CREATE TABLE books_duplicates (
+    id TEXT PRIMARY KEY,
+    kept_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    edition INTEGER NOT NULL,
+    isbn TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+INSERT INTO books_duplicates (id, kept_id, title, author, edition, isbn, version, created_at, updated_at)
+    SELECT id, kept_id, title, author, edition, isbn, version, created_at, updated_at
+    FROM (
+        SELECT *,
+            row_number() OVER (PARTITION BY isbn, edition ORDER BY created_at, id) AS n,
+            first_value(id) OVER (PARTITION BY isbn, edition ORDER BY created_at, id) AS kept_id
+        FROM books
+    ) AS ranked
+    WHERE n > 1;
+DELETE FROM books WHERE id IN (SELECT id FROM books_duplicates);
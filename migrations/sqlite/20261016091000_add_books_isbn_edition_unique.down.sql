@@ -0,0 +1 @@
+DROP INDEX books_isbn_edition_key;
@@ -0,0 +1,2 @@
+DROP INDEX books_deleted_at_idx;
+ALTER TABLE books DROP COLUMN deleted_at;
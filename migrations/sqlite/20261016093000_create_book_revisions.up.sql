@@ -0,0 +1,14 @@
+CREATE TABLE book_revisions (
+    book_id TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    title TEXT NOT NULL,
+    author TEXT NOT NULL,
+    edition INTEGER NOT NULL,
+    isbn TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (book_id, revision)
+);
+INSERT INTO book_revisions (book_id, revision, action, actor, title, author, edition, isbn, created_at)
+    SELECT id, version, 'snapshot', 'system', title, author, edition, isbn, COALESCE(updated_at, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) FROM books;
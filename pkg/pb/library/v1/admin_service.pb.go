@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        v5.29.3
+// source: proto/admin_service.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetDatabaseStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDatabaseStatsRequest) Reset() {
+	*x = GetDatabaseStatsRequest{}
+	mi := &file_proto_admin_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDatabaseStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDatabaseStatsRequest) ProtoMessage() {}
+
+func (x *GetDatabaseStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDatabaseStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetDatabaseStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_service_proto_rawDescGZIP(), []int{0}
+}
+
+// A snapshot of the database connection pool. Counters are cumulative since
+// the server started.
+type DatabaseStats struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Zero means the number of open connections is not limited.
+	MaxOpenConnections int32 `protobuf:"varint,1,opt,name=max_open_connections,json=maxOpenConnections,proto3" json:"max_open_connections,omitempty"`
+	OpenConnections    int32 `protobuf:"varint,2,opt,name=open_connections,json=openConnections,proto3" json:"open_connections,omitempty"`
+	InUse              int32 `protobuf:"varint,3,opt,name=in_use,json=inUse,proto3" json:"in_use,omitempty"`
+	Idle               int32 `protobuf:"varint,4,opt,name=idle,proto3" json:"idle,omitempty"`
+	// How many times a caller had to wait for a free connection, and for how
+	// long in total. A steadily growing wait_count means the pool is too small.
+	WaitCount    int64                `protobuf:"varint,5,opt,name=wait_count,json=waitCount,proto3" json:"wait_count,omitempty"`
+	WaitDuration *durationpb.Duration `protobuf:"bytes,6,opt,name=wait_duration,json=waitDuration,proto3" json:"wait_duration,omitempty"`
+	// Connections closed because of max_idle_conns, conn_max_idle_time and
+	// conn_max_lifetime respectively.
+	MaxIdleClosed     int64 `protobuf:"varint,7,opt,name=max_idle_closed,json=maxIdleClosed,proto3" json:"max_idle_closed,omitempty"`
+	MaxIdleTimeClosed int64 `protobuf:"varint,8,opt,name=max_idle_time_closed,json=maxIdleTimeClosed,proto3" json:"max_idle_time_closed,omitempty"`
+	MaxLifetimeClosed int64 `protobuf:"varint,9,opt,name=max_lifetime_closed,json=maxLifetimeClosed,proto3" json:"max_lifetime_closed,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *DatabaseStats) Reset() {
+	*x = DatabaseStats{}
+	mi := &file_proto_admin_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DatabaseStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabaseStats) ProtoMessage() {}
+
+func (x *DatabaseStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabaseStats.ProtoReflect.Descriptor instead.
+func (*DatabaseStats) Descriptor() ([]byte, []int) {
+	return file_proto_admin_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DatabaseStats) GetMaxOpenConnections() int32 {
+	if x != nil {
+		return x.MaxOpenConnections
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetOpenConnections() int32 {
+	if x != nil {
+		return x.OpenConnections
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetInUse() int32 {
+	if x != nil {
+		return x.InUse
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetIdle() int32 {
+	if x != nil {
+		return x.Idle
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetWaitCount() int64 {
+	if x != nil {
+		return x.WaitCount
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetWaitDuration() *durationpb.Duration {
+	if x != nil {
+		return x.WaitDuration
+	}
+	return nil
+}
+
+func (x *DatabaseStats) GetMaxIdleClosed() int64 {
+	if x != nil {
+		return x.MaxIdleClosed
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetMaxIdleTimeClosed() int64 {
+	if x != nil {
+		return x.MaxIdleTimeClosed
+	}
+	return 0
+}
+
+func (x *DatabaseStats) GetMaxLifetimeClosed() int64 {
+	if x != nil {
+		return x.MaxLifetimeClosed
+	}
+	return 0
+}
+
+var File_proto_admin_service_proto protoreflect.FileDescriptor
+
+const file_proto_admin_service_proto_rawDesc = "" +
+	"\n" +
+	"\x19proto/admin_service.proto\x12\n" +
+	"library.v1\x1a\x1egoogle/protobuf/duration.proto\"\x19\n" +
+	"\x17GetDatabaseStatsRequest\"\xff\x02\n" +
+	"\rDatabaseStats\x120\n" +
+	"\x14max_open_connections\x18\x01 \x01(\x05R\x12maxOpenConnections\x12)\n" +
+	"\x10open_connections\x18\x02 \x01(\x05R\x0fopenConnections\x12\x15\n" +
+	"\x06in_use\x18\x03 \x01(\x05R\x05inUse\x12\x12\n" +
+	"\x04idle\x18\x04 \x01(\x05R\x04idle\x12\x1d\n" +
+	"\n" +
+	"wait_count\x18\x05 \x01(\x03R\twaitCount\x12>\n" +
+	"\rwait_duration\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\fwaitDuration\x12&\n" +
+	"\x0fmax_idle_closed\x18\a \x01(\x03R\rmaxIdleClosed\x12/\n" +
+	"\x14max_idle_time_closed\x18\b \x01(\x03R\x11maxIdleTimeClosed\x12.\n" +
+	"\x13max_lifetime_closed\x18\t \x01(\x03R\x11maxLifetimeClosed2b\n" +
+	"\fAdminService\x12R\n" +
+	"\x10GetDatabaseStats\x12#.library.v1.GetDatabaseStatsRequest\x1a\x19.library.v1.DatabaseStatsB\fZ\n" +
+	"library/v1b\x06proto3"
+
+var (
+	file_proto_admin_service_proto_rawDescOnce sync.Once
+	file_proto_admin_service_proto_rawDescData []byte
+)
+
+func file_proto_admin_service_proto_rawDescGZIP() []byte {
+	file_proto_admin_service_proto_rawDescOnce.Do(func() {
+		file_proto_admin_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_admin_service_proto_rawDesc), len(file_proto_admin_service_proto_rawDesc)))
+	})
+	return file_proto_admin_service_proto_rawDescData
+}
+
+var file_proto_admin_service_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_admin_service_proto_goTypes = []any{
+	(*GetDatabaseStatsRequest)(nil), // 0: library.v1.GetDatabaseStatsRequest
+	(*DatabaseStats)(nil),           // 1: library.v1.DatabaseStats
+	(*durationpb.Duration)(nil),     // 2: google.protobuf.Duration
+}
+var file_proto_admin_service_proto_depIdxs = []int32{
+	2, // 0: library.v1.DatabaseStats.wait_duration:type_name -> google.protobuf.Duration
+	0, // 1: library.v1.AdminService.GetDatabaseStats:input_type -> library.v1.GetDatabaseStatsRequest
+	1, // 2: library.v1.AdminService.GetDatabaseStats:output_type -> library.v1.DatabaseStats
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_service_proto_init() }
+func file_proto_admin_service_proto_init() {
+	if File_proto_admin_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_admin_service_proto_rawDesc), len(file_proto_admin_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_admin_service_proto_goTypes,
+		DependencyIndexes: file_proto_admin_service_proto_depIdxs,
+		MessageInfos:      file_proto_admin_service_proto_msgTypes,
+	}.Build()
+	File_proto_admin_service_proto = out.File
+	file_proto_admin_service_proto_goTypes = nil
+	file_proto_admin_service_proto_depIdxs = nil
+}
@@ -9,6 +9,8 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,6 +23,129 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type BookRevision_Action int32
+
+const (
+	BookRevision_ACTION_UNSPECIFIED BookRevision_Action = 0
+	BookRevision_CREATE             BookRevision_Action = 1
+	BookRevision_UPDATE             BookRevision_Action = 2
+	BookRevision_DELETE             BookRevision_Action = 3
+	BookRevision_UNDELETE           BookRevision_Action = 4
+	BookRevision_PURGE              BookRevision_Action = 5
+	BookRevision_RESTORE            BookRevision_Action = 6
+	// Baseline for a book that existed before history was kept.
+	BookRevision_SNAPSHOT BookRevision_Action = 7
+)
+
+// Enum value maps for BookRevision_Action.
+var (
+	BookRevision_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "CREATE",
+		2: "UPDATE",
+		3: "DELETE",
+		4: "UNDELETE",
+		5: "PURGE",
+		6: "RESTORE",
+		7: "SNAPSHOT",
+	}
+	BookRevision_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"CREATE":             1,
+		"UPDATE":             2,
+		"DELETE":             3,
+		"UNDELETE":           4,
+		"PURGE":              5,
+		"RESTORE":            6,
+		"SNAPSHOT":           7,
+	}
+)
+
+func (x BookRevision_Action) Enum() *BookRevision_Action {
+	p := new(BookRevision_Action)
+	*p = x
+	return p
+}
+
+func (x BookRevision_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BookRevision_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_book_model_proto_enumTypes[0].Descriptor()
+}
+
+func (BookRevision_Action) Type() protoreflect.EnumType {
+	return &file_proto_book_model_proto_enumTypes[0]
+}
+
+func (x BookRevision_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BookRevision_Action.Descriptor instead.
+func (BookRevision_Action) EnumDescriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{15, 0}
+}
+
+type BookEvent_Type int32
+
+const (
+	BookEvent_TYPE_UNSPECIFIED BookEvent_Type = 0
+	BookEvent_CREATED          BookEvent_Type = 1
+	BookEvent_UPDATED          BookEvent_Type = 2
+	BookEvent_DELETED          BookEvent_Type = 3
+	BookEvent_UNDELETED        BookEvent_Type = 4
+	BookEvent_PURGED           BookEvent_Type = 5
+)
+
+// Enum value maps for BookEvent_Type.
+var (
+	BookEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+		4: "UNDELETED",
+		5: "PURGED",
+	}
+	BookEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+		"UNDELETED":        4,
+		"PURGED":           5,
+	}
+)
+
+func (x BookEvent_Type) Enum() *BookEvent_Type {
+	p := new(BookEvent_Type)
+	*p = x
+	return p
+}
+
+func (x BookEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BookEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_book_model_proto_enumTypes[1].Descriptor()
+}
+
+func (BookEvent_Type) Type() protoreflect.EnumType {
+	return &file_proto_book_model_proto_enumTypes[1]
+}
+
+func (x BookEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BookEvent_Type.Descriptor instead.
+func (BookEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{17, 0}
+}
+
 type CreateBookRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
@@ -90,8 +215,11 @@ func (x *CreateBookRequest) GetIsbn() string {
 }
 
 type GetBookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The book ID, or its ISBN-10 or ISBN-13 with or without hyphens.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Also return the book when it is soft-deleted.
+	ShowDeleted   bool `protobuf:"varint,2,opt,name=show_deleted,json=showDeleted,proto3" json:"show_deleted,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -133,20 +261,123 @@ func (x *GetBookRequest) GetId() string {
 	return ""
 }
 
+func (x *GetBookRequest) GetShowDeleted() bool {
+	if x != nil {
+		return x.ShowDeleted
+	}
+	return false
+}
+
+type GetBookByIsbnRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ISBN-10 or ISBN-13, with or without hyphens.
+	Isbn          string `protobuf:"bytes,1,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookByIsbnRequest) Reset() {
+	*x = GetBookByIsbnRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookByIsbnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookByIsbnRequest) ProtoMessage() {}
+
+func (x *GetBookByIsbnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookByIsbnRequest.ProtoReflect.Descriptor instead.
+func (*GetBookByIsbnRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetBookByIsbnRequest) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+type GetBookByIsbnResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Every edition registered under the ISBN, ordered by edition.
+	Books         []*Book `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookByIsbnResponse) Reset() {
+	*x = GetBookByIsbnResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookByIsbnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookByIsbnResponse) ProtoMessage() {}
+
+func (x *GetBookByIsbnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookByIsbnResponse.ProtoReflect.Descriptor instead.
+func (*GetBookByIsbnResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBookByIsbnResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
 type UpdateBookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
-	Edition       int32                  `protobuf:"varint,4,opt,name=edition,proto3" json:"edition,omitempty"`
-	Isbn          string                 `protobuf:"bytes,5,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Author  string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Edition int32                  `protobuf:"varint,4,opt,name=edition,proto3" json:"edition,omitempty"`
+	Isbn    string                 `protobuf:"bytes,5,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	// Fields to update: any of title, author, edition and isbn, or "*" to
+	// replace all of them. When unset, only the non-empty fields are updated.
+	UpdateMask *fieldmaskpb.FieldMask `protobuf:"bytes,6,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	// When set, the update fails with ABORTED unless it matches the stored
+	// book's etag.
+	Etag          string `protobuf:"bytes,7,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateBookRequest) Reset() {
 	*x = UpdateBookRequest{}
-	mi := &file_proto_book_model_proto_msgTypes[2]
+	mi := &file_proto_book_model_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -158,7 +389,7 @@ func (x *UpdateBookRequest) String() string {
 func (*UpdateBookRequest) ProtoMessage() {}
 
 func (x *UpdateBookRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_book_model_proto_msgTypes[2]
+	mi := &file_proto_book_model_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -171,7 +402,7 @@ func (x *UpdateBookRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateBookRequest.ProtoReflect.Descriptor instead.
 func (*UpdateBookRequest) Descriptor() ([]byte, []int) {
-	return file_proto_book_model_proto_rawDescGZIP(), []int{2}
+	return file_proto_book_model_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdateBookRequest) GetId() string {
@@ -209,16 +440,36 @@ func (x *UpdateBookRequest) GetIsbn() string {
 	return ""
 }
 
+func (x *UpdateBookRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateBookRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// Soft-deletes a book. It is hidden from reads unless show_deleted is set,
+// can be restored with UndeleteBook and is purged once the retention period
+// has passed.
 type DeleteBookRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// When set, the delete fails with ABORTED unless it matches the stored
+	// book's etag.
+	Etag          string `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteBookRequest) Reset() {
 	*x = DeleteBookRequest{}
-	mi := &file_proto_book_model_proto_msgTypes[3]
+	mi := &file_proto_book_model_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -230,7 +481,7 @@ func (x *DeleteBookRequest) String() string {
 func (*DeleteBookRequest) ProtoMessage() {}
 
 func (x *DeleteBookRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_book_model_proto_msgTypes[3]
+	mi := &file_proto_book_model_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -243,7 +494,7 @@ func (x *DeleteBookRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteBookRequest.ProtoReflect.Descriptor instead.
 func (*DeleteBookRequest) Descriptor() ([]byte, []int) {
-	return file_proto_book_model_proto_rawDescGZIP(), []int{3}
+	return file_proto_book_model_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *DeleteBookRequest) GetId() string {
@@ -253,27 +504,38 @@ func (x *DeleteBookRequest) GetId() string {
 	return ""
 }
 
-type ListBooksRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *DeleteBookRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+type UndeleteBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// When set, the undelete fails with ABORTED unless it matches the stored
+	// book's etag.
+	Etag          string `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListBooksRequest) Reset() {
-	*x = ListBooksRequest{}
-	mi := &file_proto_book_model_proto_msgTypes[4]
+func (x *UndeleteBookRequest) Reset() {
+	*x = UndeleteBookRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListBooksRequest) String() string {
+func (x *UndeleteBookRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListBooksRequest) ProtoMessage() {}
+func (*UndeleteBookRequest) ProtoMessage() {}
 
-func (x *ListBooksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_book_model_proto_msgTypes[4]
+func (x *UndeleteBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -284,33 +546,52 @@ func (x *ListBooksRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListBooksRequest.ProtoReflect.Descriptor instead.
-func (*ListBooksRequest) Descriptor() ([]byte, []int) {
-	return file_proto_book_model_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use UndeleteBookRequest.ProtoReflect.Descriptor instead.
+func (*UndeleteBookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{6}
 }
 
-type ListBooksResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+func (x *UndeleteBookRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UndeleteBookRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// Permanently removes a soft-deleted book without waiting for the retention
+// period. Books that are not deleted fail with FAILED_PRECONDITION.
+type PurgeBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// When set, the purge fails with ABORTED unless it matches the stored
+	// book's etag.
+	Etag          string `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListBooksResponse) Reset() {
-	*x = ListBooksResponse{}
-	mi := &file_proto_book_model_proto_msgTypes[5]
+func (x *PurgeBookRequest) Reset() {
+	*x = PurgeBookRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListBooksResponse) String() string {
+func (x *PurgeBookRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListBooksResponse) ProtoMessage() {}
+func (*PurgeBookRequest) ProtoMessage() {}
 
-func (x *ListBooksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_book_model_proto_msgTypes[5]
+func (x *PurgeBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -321,44 +602,60 @@ func (x *ListBooksResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListBooksResponse.ProtoReflect.Descriptor instead.
-func (*ListBooksResponse) Descriptor() ([]byte, []int) {
-	return file_proto_book_model_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use PurgeBookRequest.ProtoReflect.Descriptor instead.
+func (*PurgeBookRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *ListBooksResponse) GetBooks() []*Book {
+func (x *PurgeBookRequest) GetId() string {
 	if x != nil {
-		return x.Books
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-type Book struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Author        string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
-	Edition       int32                  `protobuf:"varint,4,opt,name=edition,proto3" json:"edition,omitempty"`
-	Isbn          string                 `protobuf:"bytes,5,opt,name=isbn,proto3" json:"isbn,omitempty"`
+func (x *PurgeBookRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+type ListBooksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of books to return. Defaults to 50, capped at 1000.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Opaque token returned as next_page_token by a previous call. The
+	// filter and order_by must match the call that produced the token.
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// AIP-160 filter over title, author, edition, isbn, create_time and
+	// update_time, e.g. `author = "Donovan" AND edition > 1`. At most 4096
+	// bytes, with parentheses nested at most 64 levels deep.
+	Filter string `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Comma separated fields with optional "desc", e.g. "title, edition desc".
+	// At most 4096 bytes.
+	OrderBy string `protobuf:"bytes,4,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// Include soft-deleted books.
+	ShowDeleted   bool `protobuf:"varint,5,opt,name=show_deleted,json=showDeleted,proto3" json:"show_deleted,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Book) Reset() {
-	*x = Book{}
-	mi := &file_proto_book_model_proto_msgTypes[6]
+func (x *ListBooksRequest) Reset() {
+	*x = ListBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Book) String() string {
+func (x *ListBooksRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Book) ProtoMessage() {}
+func (*ListBooksRequest) ProtoMessage() {}
 
-func (x *Book) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_book_model_proto_msgTypes[6]
+func (x *ListBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -369,76 +666,1236 @@ func (x *Book) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Book.ProtoReflect.Descriptor instead.
-func (*Book) Descriptor() ([]byte, []int) {
-	return file_proto_book_model_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ListBooksRequest.ProtoReflect.Descriptor instead.
+func (*ListBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *Book) GetId() string {
+func (x *ListBooksRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.Id
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListBooksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
 	}
 	return ""
 }
 
-func (x *Book) GetTitle() string {
+func (x *ListBooksRequest) GetFilter() string {
 	if x != nil {
-		return x.Title
+		return x.Filter
 	}
 	return ""
 }
 
-func (x *Book) GetAuthor() string {
+func (x *ListBooksRequest) GetOrderBy() string {
 	if x != nil {
-		return x.Author
+		return x.OrderBy
 	}
 	return ""
 }
 
-func (x *Book) GetEdition() int32 {
+func (x *ListBooksRequest) GetShowDeleted() bool {
 	if x != nil {
-		return x.Edition
+		return x.ShowDeleted
 	}
-	return 0
+	return false
 }
 
-func (x *Book) GetIsbn() string {
+type ListBooksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Books []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	// Empty when there are no further pages.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// Number of books matching the filter. Only set on the first page, when
+	// page_token is empty.
+	TotalSize     int32 `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBooksResponse) Reset() {
+	*x = ListBooksResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBooksResponse) ProtoMessage() {}
+
+func (x *ListBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[9]
 	if x != nil {
-		return x.Isbn
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-var File_proto_book_model_proto protoreflect.FileDescriptor
+// Deprecated: Use ListBooksResponse.ProtoReflect.Descriptor instead.
+func (*ListBooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{9}
+}
 
-const file_proto_book_model_proto_rawDesc = "" +
+func (x *ListBooksResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+func (x *ListBooksResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListBooksResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+// Streams every matching book without paging. Accepts the same filter and
+// order_by syntax as ListBooksRequest.
+type StreamBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        string                 `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	OrderBy       string                 `protobuf:"bytes,2,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	ShowDeleted   bool                   `protobuf:"varint,3,opt,name=show_deleted,json=showDeleted,proto3" json:"show_deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamBooksRequest) Reset() {
+	*x = StreamBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamBooksRequest) ProtoMessage() {}
+
+func (x *StreamBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamBooksRequest.ProtoReflect.Descriptor instead.
+func (*StreamBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamBooksRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *StreamBooksRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *StreamBooksRequest) GetShowDeleted() bool {
+	if x != nil {
+		return x.ShowDeleted
+	}
+	return false
+}
+
+type WatchBooksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cursor of the last event the client processed. Events after it are
+	// replayed before live ones. When empty, only changes made after the
+	// call starts are sent.
+	ResumeCursor  string `protobuf:"bytes,1,opt,name=resume_cursor,json=resumeCursor,proto3" json:"resume_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchBooksRequest) Reset() {
+	*x = WatchBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBooksRequest) ProtoMessage() {}
+
+func (x *WatchBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBooksRequest.ProtoReflect.Descriptor instead.
+func (*WatchBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchBooksRequest) GetResumeCursor() string {
+	if x != nil {
+		return x.ResumeCursor
+	}
+	return ""
+}
+
+type ListBookRevisionsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	BookId string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// Maximum number of revisions to return. Defaults to 50, capped at 1000.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Opaque token returned as next_page_token by a previous call for the
+	// same book.
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBookRevisionsRequest) Reset() {
+	*x = ListBookRevisionsRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBookRevisionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBookRevisionsRequest) ProtoMessage() {}
+
+func (x *ListBookRevisionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBookRevisionsRequest.ProtoReflect.Descriptor instead.
+func (*ListBookRevisionsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListBookRevisionsRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *ListBookRevisionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListBookRevisionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListBookRevisionsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Oldest first.
+	Revisions []*BookRevision `protobuf:"bytes,1,rep,name=revisions,proto3" json:"revisions,omitempty"`
+	// Empty when there are no further pages.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBookRevisionsResponse) Reset() {
+	*x = ListBookRevisionsResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBookRevisionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBookRevisionsResponse) ProtoMessage() {}
+
+func (x *ListBookRevisionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBookRevisionsResponse.ProtoReflect.Descriptor instead.
+func (*ListBookRevisionsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListBookRevisionsResponse) GetRevisions() []*BookRevision {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+func (x *ListBookRevisionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// Sets the book's title, author, edition and isbn back to the values it had
+// at a revision. The restore is itself recorded as a new revision.
+type RestoreBookRevisionRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	BookId   string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	Revision int64                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	// When set, the restore fails with ABORTED unless it matches the stored
+	// book's etag.
+	Etag          string `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreBookRevisionRequest) Reset() {
+	*x = RestoreBookRevisionRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreBookRevisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreBookRevisionRequest) ProtoMessage() {}
+
+func (x *RestoreBookRevisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreBookRevisionRequest.ProtoReflect.Descriptor instead.
+func (*RestoreBookRevisionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RestoreBookRevisionRequest) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *RestoreBookRevisionRequest) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *RestoreBookRevisionRequest) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+// An entry in a book's audit history.
+type BookRevision struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	BookId string                 `protobuf:"bytes,1,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	// Increases with every change and matches the book's etag right after
+	// it. A purge takes the number following the last etag.
+	Revision int64               `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	Action   BookRevision_Action `protobuf:"varint,3,opt,name=action,proto3,enum=library.v1.BookRevision_Action" json:"action,omitempty"`
+	// Who made the change: the authenticated caller, "system" for background
+	// jobs, or, on servers without authentication, the x-actor request
+	// header prefixed with "unverified:" ("anonymous" without one).
+	Actor      string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// Fields that differ from the previous revision. On the first revision
+	// every field is listed with an empty old_value.
+	Changes []*FieldChange `protobuf:"bytes,6,rep,name=changes,proto3" json:"changes,omitempty"`
+	// The book's title, author, edition and isbn after the change.
+	Book          *Book `protobuf:"bytes,7,opt,name=book,proto3" json:"book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookRevision) Reset() {
+	*x = BookRevision{}
+	mi := &file_proto_book_model_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookRevision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookRevision) ProtoMessage() {}
+
+func (x *BookRevision) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookRevision.ProtoReflect.Descriptor instead.
+func (*BookRevision) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BookRevision) GetBookId() string {
+	if x != nil {
+		return x.BookId
+	}
+	return ""
+}
+
+func (x *BookRevision) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *BookRevision) GetAction() BookRevision_Action {
+	if x != nil {
+		return x.Action
+	}
+	return BookRevision_ACTION_UNSPECIFIED
+}
+
+func (x *BookRevision) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *BookRevision) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *BookRevision) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *BookRevision) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+type FieldChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	OldValue      string                 `protobuf:"bytes,2,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	NewValue      string                 `protobuf:"bytes,3,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldChange) Reset() {
+	*x = FieldChange{}
+	mi := &file_proto_book_model_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldChange) ProtoMessage() {}
+
+func (x *FieldChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldChange.ProtoReflect.Descriptor instead.
+func (*FieldChange) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *FieldChange) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldChange) GetOldValue() string {
+	if x != nil {
+		return x.OldValue
+	}
+	return ""
+}
+
+func (x *FieldChange) GetNewValue() string {
+	if x != nil {
+		return x.NewValue
+	}
+	return ""
+}
+
+// A change to the catalog made through this service.
+type BookEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  BookEvent_Type         `protobuf:"varint,1,opt,name=type,proto3,enum=library.v1.BookEvent_Type" json:"type,omitempty"`
+	// The book after the change. For DELETED and PURGED events only the id
+	// is set.
+	Book *Book `protobuf:"bytes,2,opt,name=book,proto3" json:"book,omitempty"`
+	// Pass as resume_cursor to continue after this event.
+	Cursor        string                 `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	EventTime     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=event_time,json=eventTime,proto3" json:"event_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookEvent) Reset() {
+	*x = BookEvent{}
+	mi := &file_proto_book_model_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookEvent) ProtoMessage() {}
+
+func (x *BookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookEvent.ProtoReflect.Descriptor instead.
+func (*BookEvent) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BookEvent) GetType() BookEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return BookEvent_TYPE_UNSPECIFIED
+}
+
+func (x *BookEvent) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+func (x *BookEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *BookEvent) GetEventTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EventTime
+	}
+	return nil
+}
+
+type BatchCreateBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*CreateBookRequest   `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	AllowPartial  bool                   `protobuf:"varint,2,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateBooksRequest) Reset() {
+	*x = BatchCreateBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateBooksRequest) ProtoMessage() {}
+
+func (x *BatchCreateBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateBooksRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchCreateBooksRequest) GetRequests() []*CreateBookRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *BatchCreateBooksRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+type BatchCreateBooksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per request, in request order.
+	Results       []*BatchBookResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateBooksResponse) Reset() {
+	*x = BatchCreateBooksResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateBooksResponse) ProtoMessage() {}
+
+func (x *BatchCreateBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateBooksResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateBooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchCreateBooksResponse) GetResults() []*BatchBookResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchGetBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	AllowPartial  bool                   `protobuf:"varint,2,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetBooksRequest) Reset() {
+	*x = BatchGetBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetBooksRequest) ProtoMessage() {}
+
+func (x *BatchGetBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetBooksRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BatchGetBooksRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *BatchGetBooksRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+type BatchGetBooksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per id, in request order.
+	Results       []*BatchBookResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetBooksResponse) Reset() {
+	*x = BatchGetBooksResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetBooksResponse) ProtoMessage() {}
+
+func (x *BatchGetBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetBooksResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetBooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BatchGetBooksResponse) GetResults() []*BatchBookResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchDeleteBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	AllowPartial  bool                   `protobuf:"varint,2,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteBooksRequest) Reset() {
+	*x = BatchDeleteBooksRequest{}
+	mi := &file_proto_book_model_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteBooksRequest) ProtoMessage() {}
+
+func (x *BatchDeleteBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteBooksRequest.ProtoReflect.Descriptor instead.
+func (*BatchDeleteBooksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BatchDeleteBooksRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *BatchDeleteBooksRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+type BatchDeleteBooksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per id, in request order.
+	Results       []*BatchBookResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteBooksResponse) Reset() {
+	*x = BatchDeleteBooksResponse{}
+	mi := &file_proto_book_model_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteBooksResponse) ProtoMessage() {}
+
+func (x *BatchDeleteBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteBooksResponse.ProtoReflect.Descriptor instead.
+func (*BatchDeleteBooksResponse) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BatchDeleteBooksResponse) GetResults() []*BatchBookResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// Outcome of a single batch item.
+type BatchBookResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The created or fetched book when the item succeeded.
+	Book *Book `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
+	// A google.rpc.Code value; zero (OK) on success.
+	Code          int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchBookResult) Reset() {
+	*x = BatchBookResult{}
+	mi := &file_proto_book_model_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchBookResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchBookResult) ProtoMessage() {}
+
+func (x *BatchBookResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchBookResult.ProtoReflect.Descriptor instead.
+func (*BatchBookResult) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BatchBookResult) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+func (x *BatchBookResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchBookResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Book struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Author  string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Edition int32                  `protobuf:"varint,4,opt,name=edition,proto3" json:"edition,omitempty"`
+	// Canonical ISBN-13 without hyphens.
+	Isbn string `protobuf:"bytes,5,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	// Changes on every write; send it back on update or delete to detect
+	// concurrent modifications.
+	Etag       string                 `protobuf:"bytes,6,opt,name=etag,proto3" json:"etag,omitempty"`
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	// Set while the book is soft-deleted. A deleted book keeps its ISBN and
+	// edition, so creating a duplicate fails with ALREADY_EXISTS naming it.
+	DeleteTime    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=delete_time,json=deleteTime,proto3" json:"delete_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Book) Reset() {
+	*x = Book{}
+	mi := &file_proto_book_model_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_book_model_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_proto_book_model_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Book) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Book) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Book) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Book) GetEdition() int32 {
+	if x != nil {
+		return x.Edition
+	}
+	return 0
+}
+
+func (x *Book) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+func (x *Book) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Book) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Book) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+func (x *Book) GetDeleteTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeleteTime
+	}
+	return nil
+}
+
+var File_proto_book_model_proto protoreflect.FileDescriptor
+
+const file_proto_book_model_proto_rawDesc = "" +
 	"\n" +
 	"\x16proto/book_model.proto\x12\n" +
-	"library.v1\"o\n" +
+	"library.v1\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"o\n" +
 	"\x11CreateBookRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
 	"\x06author\x18\x02 \x01(\tR\x06author\x12\x18\n" +
 	"\aedition\x18\x03 \x01(\x05R\aedition\x12\x12\n" +
-	"\x04isbn\x18\x04 \x01(\tR\x04isbn\" \n" +
+	"\x04isbn\x18\x04 \x01(\tR\x04isbn\"C\n" +
 	"\x0eGetBookRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x7f\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fshow_deleted\x18\x02 \x01(\bR\vshowDeleted\"*\n" +
+	"\x14GetBookByIsbnRequest\x12\x12\n" +
+	"\x04isbn\x18\x01 \x01(\tR\x04isbn\"?\n" +
+	"\x15GetBookByIsbnResponse\x12&\n" +
+	"\x05books\x18\x01 \x03(\v2\x10.library.v1.BookR\x05books\"\xd0\x01\n" +
 	"\x11UpdateBookRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
 	"\x06author\x18\x03 \x01(\tR\x06author\x12\x18\n" +
 	"\aedition\x18\x04 \x01(\x05R\aedition\x12\x12\n" +
-	"\x04isbn\x18\x05 \x01(\tR\x04isbn\"#\n" +
+	"\x04isbn\x18\x05 \x01(\tR\x04isbn\x12;\n" +
+	"\vupdate_mask\x18\x06 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12\x12\n" +
+	"\x04etag\x18\a \x01(\tR\x04etag\"7\n" +
 	"\x11DeleteBookRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\x12\n" +
-	"\x10ListBooksRequest\";\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"9\n" +
+	"\x13UndeleteBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"6\n" +
+	"\x10PurgeBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"\xa4\x01\n" +
+	"\x10ListBooksRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06filter\x18\x03 \x01(\tR\x06filter\x12\x19\n" +
+	"\border_by\x18\x04 \x01(\tR\aorderBy\x12!\n" +
+	"\fshow_deleted\x18\x05 \x01(\bR\vshowDeleted\"\x82\x01\n" +
 	"\x11ListBooksResponse\x12&\n" +
-	"\x05books\x18\x01 \x03(\v2\x10.library.v1.BookR\x05books\"r\n" +
+	"\x05books\x18\x01 \x03(\v2\x10.library.v1.BookR\x05books\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x05R\ttotalSize\"j\n" +
+	"\x12StreamBooksRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\x12\x19\n" +
+	"\border_by\x18\x02 \x01(\tR\aorderBy\x12!\n" +
+	"\fshow_deleted\x18\x03 \x01(\bR\vshowDeleted\"8\n" +
+	"\x11WatchBooksRequest\x12#\n" +
+	"\rresume_cursor\x18\x01 \x01(\tR\fresumeCursor\"o\n" +
+	"\x18ListBookRevisionsRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"{\n" +
+	"\x19ListBookRevisionsResponse\x126\n" +
+	"\trevisions\x18\x01 \x03(\v2\x18.library.v1.BookRevisionR\trevisions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"e\n" +
+	"\x1aRestoreBookRevisionRequest\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x03R\brevision\x12\x12\n" +
+	"\x04etag\x18\x03 \x01(\tR\x04etag\"\xa2\x03\n" +
+	"\fBookRevision\x12\x17\n" +
+	"\abook_id\x18\x01 \x01(\tR\x06bookId\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x03R\brevision\x127\n" +
+	"\x06action\x18\x03 \x01(\x0e2\x1f.library.v1.BookRevision.ActionR\x06action\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12;\n" +
+	"\vcreate_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x121\n" +
+	"\achanges\x18\x06 \x03(\v2\x17.library.v1.FieldChangeR\achanges\x12$\n" +
+	"\x04book\x18\a \x01(\v2\x10.library.v1.BookR\x04book\"x\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\n" +
+	"\n" +
+	"\x06CREATE\x10\x01\x12\n" +
+	"\n" +
+	"\x06UPDATE\x10\x02\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x03\x12\f\n" +
+	"\bUNDELETE\x10\x04\x12\t\n" +
+	"\x05PURGE\x10\x05\x12\v\n" +
+	"\aRESTORE\x10\x06\x12\f\n" +
+	"\bSNAPSHOT\x10\a\"]\n" +
+	"\vFieldChange\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x1b\n" +
+	"\told_value\x18\x02 \x01(\tR\boldValue\x12\x1b\n" +
+	"\tnew_value\x18\x03 \x01(\tR\bnewValue\"\x94\x02\n" +
+	"\tBookEvent\x12.\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1a.library.v1.BookEvent.TypeR\x04type\x12$\n" +
+	"\x04book\x18\x02 \x01(\v2\x10.library.v1.BookR\x04book\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\x129\n" +
+	"\n" +
+	"event_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\teventTime\"^\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x03\x12\r\n" +
+	"\tUNDELETED\x10\x04\x12\n" +
+	"\n" +
+	"\x06PURGED\x10\x05\"y\n" +
+	"\x17BatchCreateBooksRequest\x129\n" +
+	"\brequests\x18\x01 \x03(\v2\x1d.library.v1.CreateBookRequestR\brequests\x12#\n" +
+	"\rallow_partial\x18\x02 \x01(\bR\fallowPartial\"Q\n" +
+	"\x18BatchCreateBooksResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.library.v1.BatchBookResultR\aresults\"M\n" +
+	"\x14BatchGetBooksRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12#\n" +
+	"\rallow_partial\x18\x02 \x01(\bR\fallowPartial\"N\n" +
+	"\x15BatchGetBooksResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.library.v1.BatchBookResultR\aresults\"P\n" +
+	"\x17BatchDeleteBooksRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\x12#\n" +
+	"\rallow_partial\x18\x02 \x01(\bR\fallowPartial\"Q\n" +
+	"\x18BatchDeleteBooksResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.library.v1.BatchBookResultR\aresults\"e\n" +
+	"\x0fBatchBookResult\x12$\n" +
+	"\x04book\x18\x01 \x01(\v2\x10.library.v1.BookR\x04book\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\xbd\x02\n" +
 	"\x04Book\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
 	"\x06author\x18\x03 \x01(\tR\x06author\x12\x18\n" +
 	"\aedition\x18\x04 \x01(\x05R\aedition\x12\x12\n" +
-	"\x04isbn\x18\x05 \x01(\tR\x04isbnB\fZ\n" +
+	"\x04isbn\x18\x05 \x01(\tR\x04isbn\x12\x12\n" +
+	"\x04etag\x18\x06 \x01(\tR\x04etag\x12;\n" +
+	"\vcreate_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vupdate_time\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\x12;\n" +
+	"\vdelete_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"deleteTimeB\fZ\n" +
 	"library/v1b\x06proto3"
 
 var (
@@ -453,23 +1910,65 @@ func file_proto_book_model_proto_rawDescGZIP() []byte {
 	return file_proto_book_model_proto_rawDescData
 }
 
-var file_proto_book_model_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_book_model_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_book_model_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_proto_book_model_proto_goTypes = []any{
-	(*CreateBookRequest)(nil), // 0: library.v1.CreateBookRequest
-	(*GetBookRequest)(nil),    // 1: library.v1.GetBookRequest
-	(*UpdateBookRequest)(nil), // 2: library.v1.UpdateBookRequest
-	(*DeleteBookRequest)(nil), // 3: library.v1.DeleteBookRequest
-	(*ListBooksRequest)(nil),  // 4: library.v1.ListBooksRequest
-	(*ListBooksResponse)(nil), // 5: library.v1.ListBooksResponse
-	(*Book)(nil),              // 6: library.v1.Book
+	(BookRevision_Action)(0),           // 0: library.v1.BookRevision.Action
+	(BookEvent_Type)(0),                // 1: library.v1.BookEvent.Type
+	(*CreateBookRequest)(nil),          // 2: library.v1.CreateBookRequest
+	(*GetBookRequest)(nil),             // 3: library.v1.GetBookRequest
+	(*GetBookByIsbnRequest)(nil),       // 4: library.v1.GetBookByIsbnRequest
+	(*GetBookByIsbnResponse)(nil),      // 5: library.v1.GetBookByIsbnResponse
+	(*UpdateBookRequest)(nil),          // 6: library.v1.UpdateBookRequest
+	(*DeleteBookRequest)(nil),          // 7: library.v1.DeleteBookRequest
+	(*UndeleteBookRequest)(nil),        // 8: library.v1.UndeleteBookRequest
+	(*PurgeBookRequest)(nil),           // 9: library.v1.PurgeBookRequest
+	(*ListBooksRequest)(nil),           // 10: library.v1.ListBooksRequest
+	(*ListBooksResponse)(nil),          // 11: library.v1.ListBooksResponse
+	(*StreamBooksRequest)(nil),         // 12: library.v1.StreamBooksRequest
+	(*WatchBooksRequest)(nil),          // 13: library.v1.WatchBooksRequest
+	(*ListBookRevisionsRequest)(nil),   // 14: library.v1.ListBookRevisionsRequest
+	(*ListBookRevisionsResponse)(nil),  // 15: library.v1.ListBookRevisionsResponse
+	(*RestoreBookRevisionRequest)(nil), // 16: library.v1.RestoreBookRevisionRequest
+	(*BookRevision)(nil),               // 17: library.v1.BookRevision
+	(*FieldChange)(nil),                // 18: library.v1.FieldChange
+	(*BookEvent)(nil),                  // 19: library.v1.BookEvent
+	(*BatchCreateBooksRequest)(nil),    // 20: library.v1.BatchCreateBooksRequest
+	(*BatchCreateBooksResponse)(nil),   // 21: library.v1.BatchCreateBooksResponse
+	(*BatchGetBooksRequest)(nil),       // 22: library.v1.BatchGetBooksRequest
+	(*BatchGetBooksResponse)(nil),      // 23: library.v1.BatchGetBooksResponse
+	(*BatchDeleteBooksRequest)(nil),    // 24: library.v1.BatchDeleteBooksRequest
+	(*BatchDeleteBooksResponse)(nil),   // 25: library.v1.BatchDeleteBooksResponse
+	(*BatchBookResult)(nil),            // 26: library.v1.BatchBookResult
+	(*Book)(nil),                       // 27: library.v1.Book
+	(*fieldmaskpb.FieldMask)(nil),      // 28: google.protobuf.FieldMask
+	(*timestamppb.Timestamp)(nil),      // 29: google.protobuf.Timestamp
 }
 var file_proto_book_model_proto_depIdxs = []int32{
-	6, // 0: library.v1.ListBooksResponse.books:type_name -> library.v1.Book
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	27, // 0: library.v1.GetBookByIsbnResponse.books:type_name -> library.v1.Book
+	28, // 1: library.v1.UpdateBookRequest.update_mask:type_name -> google.protobuf.FieldMask
+	27, // 2: library.v1.ListBooksResponse.books:type_name -> library.v1.Book
+	17, // 3: library.v1.ListBookRevisionsResponse.revisions:type_name -> library.v1.BookRevision
+	0,  // 4: library.v1.BookRevision.action:type_name -> library.v1.BookRevision.Action
+	29, // 5: library.v1.BookRevision.create_time:type_name -> google.protobuf.Timestamp
+	18, // 6: library.v1.BookRevision.changes:type_name -> library.v1.FieldChange
+	27, // 7: library.v1.BookRevision.book:type_name -> library.v1.Book
+	1,  // 8: library.v1.BookEvent.type:type_name -> library.v1.BookEvent.Type
+	27, // 9: library.v1.BookEvent.book:type_name -> library.v1.Book
+	29, // 10: library.v1.BookEvent.event_time:type_name -> google.protobuf.Timestamp
+	2,  // 11: library.v1.BatchCreateBooksRequest.requests:type_name -> library.v1.CreateBookRequest
+	26, // 12: library.v1.BatchCreateBooksResponse.results:type_name -> library.v1.BatchBookResult
+	26, // 13: library.v1.BatchGetBooksResponse.results:type_name -> library.v1.BatchBookResult
+	26, // 14: library.v1.BatchDeleteBooksResponse.results:type_name -> library.v1.BatchBookResult
+	27, // 15: library.v1.BatchBookResult.book:type_name -> library.v1.Book
+	29, // 16: library.v1.Book.create_time:type_name -> google.protobuf.Timestamp
+	29, // 17: library.v1.Book.update_time:type_name -> google.protobuf.Timestamp
+	29, // 18: library.v1.Book.delete_time:type_name -> google.protobuf.Timestamp
+	19, // [19:19] is the sub-list for method output_type
+	19, // [19:19] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_proto_book_model_proto_init() }
@@ -482,13 +1981,14 @@ func file_proto_book_model_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_book_model_proto_rawDesc), len(file_proto_book_model_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_proto_book_model_proto_goTypes,
 		DependencyIndexes: file_proto_book_model_proto_depIdxs,
+		EnumInfos:         file_proto_book_model_proto_enumTypes,
 		MessageInfos:      file_proto_book_model_proto_msgTypes,
 	}.Build()
 	File_proto_book_model_proto = out.File
@@ -26,44 +26,91 @@ var File_proto_library_service_proto protoreflect.FileDescriptor
 const file_proto_library_service_proto_rawDesc = "" +
 	"\n" +
 	"\x1bproto/library_service.proto\x12\n" +
-	"library.v1\x1a\x16proto/book_model.proto\x1a\x1bgoogle/protobuf/empty.proto2\xd6\x02\n" +
+	"library.v1\x1a\x16proto/book_model.proto\x1a\x1bgoogle/protobuf/empty.proto2\x82\t\n" +
 	"\x0eLibraryService\x12=\n" +
 	"\n" +
 	"CreateBook\x12\x1d.library.v1.CreateBookRequest\x1a\x10.library.v1.Book\x127\n" +
-	"\aGetBook\x12\x1a.library.v1.GetBookRequest\x1a\x10.library.v1.Book\x12=\n" +
+	"\aGetBook\x12\x1a.library.v1.GetBookRequest\x1a\x10.library.v1.Book\x12T\n" +
+	"\rGetBookByIsbn\x12 .library.v1.GetBookByIsbnRequest\x1a!.library.v1.GetBookByIsbnResponse\x12=\n" +
 	"\n" +
 	"UpdateBook\x12\x1d.library.v1.UpdateBookRequest\x1a\x10.library.v1.Book\x12C\n" +
 	"\n" +
-	"DeleteBook\x12\x1d.library.v1.DeleteBookRequest\x1a\x16.google.protobuf.Empty\x12H\n" +
-	"\tListBooks\x12\x1c.library.v1.ListBooksRequest\x1a\x1d.library.v1.ListBooksResponseB\fZ\n" +
+	"DeleteBook\x12\x1d.library.v1.DeleteBookRequest\x1a\x16.google.protobuf.Empty\x12A\n" +
+	"\fUndeleteBook\x12\x1f.library.v1.UndeleteBookRequest\x1a\x10.library.v1.Book\x12A\n" +
+	"\tPurgeBook\x12\x1c.library.v1.PurgeBookRequest\x1a\x16.google.protobuf.Empty\x12`\n" +
+	"\x11ListBookRevisions\x12$.library.v1.ListBookRevisionsRequest\x1a%.library.v1.ListBookRevisionsResponse\x12O\n" +
+	"\x13RestoreBookRevision\x12&.library.v1.RestoreBookRevisionRequest\x1a\x10.library.v1.Book\x12H\n" +
+	"\tListBooks\x12\x1c.library.v1.ListBooksRequest\x1a\x1d.library.v1.ListBooksResponse\x12A\n" +
+	"\vStreamBooks\x12\x1e.library.v1.StreamBooksRequest\x1a\x10.library.v1.Book0\x01\x12D\n" +
+	"\n" +
+	"WatchBooks\x12\x1d.library.v1.WatchBooksRequest\x1a\x15.library.v1.BookEvent0\x01\x12]\n" +
+	"\x10BatchCreateBooks\x12#.library.v1.BatchCreateBooksRequest\x1a$.library.v1.BatchCreateBooksResponse\x12T\n" +
+	"\rBatchGetBooks\x12 .library.v1.BatchGetBooksRequest\x1a!.library.v1.BatchGetBooksResponse\x12]\n" +
+	"\x10BatchDeleteBooks\x12#.library.v1.BatchDeleteBooksRequest\x1a$.library.v1.BatchDeleteBooksResponseB\fZ\n" +
 	"library/v1b\x06proto3"
 
 var file_proto_library_service_proto_goTypes = []any{
-	(*CreateBookRequest)(nil), // 0: library.v1.CreateBookRequest
-	(*GetBookRequest)(nil),    // 1: library.v1.GetBookRequest
-	(*UpdateBookRequest)(nil), // 2: library.v1.UpdateBookRequest
-	(*DeleteBookRequest)(nil), // 3: library.v1.DeleteBookRequest
-	(*ListBooksRequest)(nil),  // 4: library.v1.ListBooksRequest
-	(*Book)(nil),              // 5: library.v1.Book
-	(*emptypb.Empty)(nil),     // 6: google.protobuf.Empty
-	(*ListBooksResponse)(nil), // 7: library.v1.ListBooksResponse
+	(*CreateBookRequest)(nil),          // 0: library.v1.CreateBookRequest
+	(*GetBookRequest)(nil),             // 1: library.v1.GetBookRequest
+	(*GetBookByIsbnRequest)(nil),       // 2: library.v1.GetBookByIsbnRequest
+	(*UpdateBookRequest)(nil),          // 3: library.v1.UpdateBookRequest
+	(*DeleteBookRequest)(nil),          // 4: library.v1.DeleteBookRequest
+	(*UndeleteBookRequest)(nil),        // 5: library.v1.UndeleteBookRequest
+	(*PurgeBookRequest)(nil),           // 6: library.v1.PurgeBookRequest
+	(*ListBookRevisionsRequest)(nil),   // 7: library.v1.ListBookRevisionsRequest
+	(*RestoreBookRevisionRequest)(nil), // 8: library.v1.RestoreBookRevisionRequest
+	(*ListBooksRequest)(nil),           // 9: library.v1.ListBooksRequest
+	(*StreamBooksRequest)(nil),         // 10: library.v1.StreamBooksRequest
+	(*WatchBooksRequest)(nil),          // 11: library.v1.WatchBooksRequest
+	(*BatchCreateBooksRequest)(nil),    // 12: library.v1.BatchCreateBooksRequest
+	(*BatchGetBooksRequest)(nil),       // 13: library.v1.BatchGetBooksRequest
+	(*BatchDeleteBooksRequest)(nil),    // 14: library.v1.BatchDeleteBooksRequest
+	(*Book)(nil),                       // 15: library.v1.Book
+	(*GetBookByIsbnResponse)(nil),      // 16: library.v1.GetBookByIsbnResponse
+	(*emptypb.Empty)(nil),              // 17: google.protobuf.Empty
+	(*ListBookRevisionsResponse)(nil),  // 18: library.v1.ListBookRevisionsResponse
+	(*ListBooksResponse)(nil),          // 19: library.v1.ListBooksResponse
+	(*BookEvent)(nil),                  // 20: library.v1.BookEvent
+	(*BatchCreateBooksResponse)(nil),   // 21: library.v1.BatchCreateBooksResponse
+	(*BatchGetBooksResponse)(nil),      // 22: library.v1.BatchGetBooksResponse
+	(*BatchDeleteBooksResponse)(nil),   // 23: library.v1.BatchDeleteBooksResponse
 }
 var file_proto_library_service_proto_depIdxs = []int32{
-	0, // 0: library.v1.LibraryService.CreateBook:input_type -> library.v1.CreateBookRequest
-	1, // 1: library.v1.LibraryService.GetBook:input_type -> library.v1.GetBookRequest
-	2, // 2: library.v1.LibraryService.UpdateBook:input_type -> library.v1.UpdateBookRequest
-	3, // 3: library.v1.LibraryService.DeleteBook:input_type -> library.v1.DeleteBookRequest
-	4, // 4: library.v1.LibraryService.ListBooks:input_type -> library.v1.ListBooksRequest
-	5, // 5: library.v1.LibraryService.CreateBook:output_type -> library.v1.Book
-	5, // 6: library.v1.LibraryService.GetBook:output_type -> library.v1.Book
-	5, // 7: library.v1.LibraryService.UpdateBook:output_type -> library.v1.Book
-	6, // 8: library.v1.LibraryService.DeleteBook:output_type -> google.protobuf.Empty
-	7, // 9: library.v1.LibraryService.ListBooks:output_type -> library.v1.ListBooksResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: library.v1.LibraryService.CreateBook:input_type -> library.v1.CreateBookRequest
+	1,  // 1: library.v1.LibraryService.GetBook:input_type -> library.v1.GetBookRequest
+	2,  // 2: library.v1.LibraryService.GetBookByIsbn:input_type -> library.v1.GetBookByIsbnRequest
+	3,  // 3: library.v1.LibraryService.UpdateBook:input_type -> library.v1.UpdateBookRequest
+	4,  // 4: library.v1.LibraryService.DeleteBook:input_type -> library.v1.DeleteBookRequest
+	5,  // 5: library.v1.LibraryService.UndeleteBook:input_type -> library.v1.UndeleteBookRequest
+	6,  // 6: library.v1.LibraryService.PurgeBook:input_type -> library.v1.PurgeBookRequest
+	7,  // 7: library.v1.LibraryService.ListBookRevisions:input_type -> library.v1.ListBookRevisionsRequest
+	8,  // 8: library.v1.LibraryService.RestoreBookRevision:input_type -> library.v1.RestoreBookRevisionRequest
+	9,  // 9: library.v1.LibraryService.ListBooks:input_type -> library.v1.ListBooksRequest
+	10, // 10: library.v1.LibraryService.StreamBooks:input_type -> library.v1.StreamBooksRequest
+	11, // 11: library.v1.LibraryService.WatchBooks:input_type -> library.v1.WatchBooksRequest
+	12, // 12: library.v1.LibraryService.BatchCreateBooks:input_type -> library.v1.BatchCreateBooksRequest
+	13, // 13: library.v1.LibraryService.BatchGetBooks:input_type -> library.v1.BatchGetBooksRequest
+	14, // 14: library.v1.LibraryService.BatchDeleteBooks:input_type -> library.v1.BatchDeleteBooksRequest
+	15, // 15: library.v1.LibraryService.CreateBook:output_type -> library.v1.Book
+	15, // 16: library.v1.LibraryService.GetBook:output_type -> library.v1.Book
+	16, // 17: library.v1.LibraryService.GetBookByIsbn:output_type -> library.v1.GetBookByIsbnResponse
+	15, // 18: library.v1.LibraryService.UpdateBook:output_type -> library.v1.Book
+	17, // 19: library.v1.LibraryService.DeleteBook:output_type -> google.protobuf.Empty
+	15, // 20: library.v1.LibraryService.UndeleteBook:output_type -> library.v1.Book
+	17, // 21: library.v1.LibraryService.PurgeBook:output_type -> google.protobuf.Empty
+	18, // 22: library.v1.LibraryService.ListBookRevisions:output_type -> library.v1.ListBookRevisionsResponse
+	15, // 23: library.v1.LibraryService.RestoreBookRevision:output_type -> library.v1.Book
+	19, // 24: library.v1.LibraryService.ListBooks:output_type -> library.v1.ListBooksResponse
+	15, // 25: library.v1.LibraryService.StreamBooks:output_type -> library.v1.Book
+	20, // 26: library.v1.LibraryService.WatchBooks:output_type -> library.v1.BookEvent
+	21, // 27: library.v1.LibraryService.BatchCreateBooks:output_type -> library.v1.BatchCreateBooksResponse
+	22, // 28: library.v1.LibraryService.BatchGetBooks:output_type -> library.v1.BatchGetBooksResponse
+	23, // 29: library.v1.LibraryService.BatchDeleteBooks:output_type -> library.v1.BatchDeleteBooksResponse
+	15, // [15:30] is the sub-list for method output_type
+	0,  // [0:15] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
 func init() { file_proto_library_service_proto_init() }
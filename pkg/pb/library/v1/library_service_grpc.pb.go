@@ -20,11 +20,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LibraryService_CreateBook_FullMethodName = "/library.v1.LibraryService/CreateBook"
-	LibraryService_GetBook_FullMethodName    = "/library.v1.LibraryService/GetBook"
-	LibraryService_UpdateBook_FullMethodName = "/library.v1.LibraryService/UpdateBook"
-	LibraryService_DeleteBook_FullMethodName = "/library.v1.LibraryService/DeleteBook"
-	LibraryService_ListBooks_FullMethodName  = "/library.v1.LibraryService/ListBooks"
+	LibraryService_CreateBook_FullMethodName          = "/library.v1.LibraryService/CreateBook"
+	LibraryService_GetBook_FullMethodName             = "/library.v1.LibraryService/GetBook"
+	LibraryService_GetBookByIsbn_FullMethodName       = "/library.v1.LibraryService/GetBookByIsbn"
+	LibraryService_UpdateBook_FullMethodName          = "/library.v1.LibraryService/UpdateBook"
+	LibraryService_DeleteBook_FullMethodName          = "/library.v1.LibraryService/DeleteBook"
+	LibraryService_UndeleteBook_FullMethodName        = "/library.v1.LibraryService/UndeleteBook"
+	LibraryService_PurgeBook_FullMethodName           = "/library.v1.LibraryService/PurgeBook"
+	LibraryService_ListBookRevisions_FullMethodName   = "/library.v1.LibraryService/ListBookRevisions"
+	LibraryService_RestoreBookRevision_FullMethodName = "/library.v1.LibraryService/RestoreBookRevision"
+	LibraryService_ListBooks_FullMethodName           = "/library.v1.LibraryService/ListBooks"
+	LibraryService_StreamBooks_FullMethodName         = "/library.v1.LibraryService/StreamBooks"
+	LibraryService_WatchBooks_FullMethodName          = "/library.v1.LibraryService/WatchBooks"
+	LibraryService_BatchCreateBooks_FullMethodName    = "/library.v1.LibraryService/BatchCreateBooks"
+	LibraryService_BatchGetBooks_FullMethodName       = "/library.v1.LibraryService/BatchGetBooks"
+	LibraryService_BatchDeleteBooks_FullMethodName    = "/library.v1.LibraryService/BatchDeleteBooks"
 )
 
 // LibraryServiceClient is the client API for LibraryService service.
@@ -33,9 +43,25 @@ const (
 type LibraryServiceClient interface {
 	CreateBook(ctx context.Context, in *CreateBookRequest, opts ...grpc.CallOption) (*Book, error)
 	GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*Book, error)
+	GetBookByIsbn(ctx context.Context, in *GetBookByIsbnRequest, opts ...grpc.CallOption) (*GetBookByIsbnResponse, error)
 	UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*Book, error)
 	DeleteBook(ctx context.Context, in *DeleteBookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UndeleteBook(ctx context.Context, in *UndeleteBookRequest, opts ...grpc.CallOption) (*Book, error)
+	PurgeBook(ctx context.Context, in *PurgeBookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListBookRevisions(ctx context.Context, in *ListBookRevisionsRequest, opts ...grpc.CallOption) (*ListBookRevisionsResponse, error)
+	RestoreBookRevision(ctx context.Context, in *RestoreBookRevisionRequest, opts ...grpc.CallOption) (*Book, error)
 	ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (*ListBooksResponse, error)
+	StreamBooks(ctx context.Context, in *StreamBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error)
+	// Streams the writes made through the serving replica since it started.
+	// The feed is best-effort and single-replica: writes handled by other
+	// replicas are never seen, and events are lost on restart, which makes
+	// earlier cursors fail with OUT_OF_RANGE. Servers only offer it when
+	// started with books.watch for a single-replica deployment, and fail
+	// with UNIMPLEMENTED otherwise.
+	WatchBooks(ctx context.Context, in *WatchBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BookEvent], error)
+	BatchCreateBooks(ctx context.Context, in *BatchCreateBooksRequest, opts ...grpc.CallOption) (*BatchCreateBooksResponse, error)
+	BatchGetBooks(ctx context.Context, in *BatchGetBooksRequest, opts ...grpc.CallOption) (*BatchGetBooksResponse, error)
+	BatchDeleteBooks(ctx context.Context, in *BatchDeleteBooksRequest, opts ...grpc.CallOption) (*BatchDeleteBooksResponse, error)
 }
 
 type libraryServiceClient struct {
@@ -66,6 +92,16 @@ func (c *libraryServiceClient) GetBook(ctx context.Context, in *GetBookRequest,
 	return out, nil
 }
 
+func (c *libraryServiceClient) GetBookByIsbn(ctx context.Context, in *GetBookByIsbnRequest, opts ...grpc.CallOption) (*GetBookByIsbnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBookByIsbnResponse)
+	err := c.cc.Invoke(ctx, LibraryService_GetBookByIsbn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *libraryServiceClient) UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*Book, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Book)
@@ -86,6 +122,46 @@ func (c *libraryServiceClient) DeleteBook(ctx context.Context, in *DeleteBookReq
 	return out, nil
 }
 
+func (c *libraryServiceClient) UndeleteBook(ctx context.Context, in *UndeleteBookRequest, opts ...grpc.CallOption) (*Book, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Book)
+	err := c.cc.Invoke(ctx, LibraryService_UndeleteBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryServiceClient) PurgeBook(ctx context.Context, in *PurgeBookRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LibraryService_PurgeBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryServiceClient) ListBookRevisions(ctx context.Context, in *ListBookRevisionsRequest, opts ...grpc.CallOption) (*ListBookRevisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBookRevisionsResponse)
+	err := c.cc.Invoke(ctx, LibraryService_ListBookRevisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryServiceClient) RestoreBookRevision(ctx context.Context, in *RestoreBookRevisionRequest, opts ...grpc.CallOption) (*Book, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Book)
+	err := c.cc.Invoke(ctx, LibraryService_RestoreBookRevision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *libraryServiceClient) ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (*ListBooksResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListBooksResponse)
@@ -96,15 +172,99 @@ func (c *libraryServiceClient) ListBooks(ctx context.Context, in *ListBooksReque
 	return out, nil
 }
 
+func (c *libraryServiceClient) StreamBooks(ctx context.Context, in *StreamBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LibraryService_ServiceDesc.Streams[0], LibraryService_StreamBooks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamBooksRequest, Book]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LibraryService_StreamBooksClient = grpc.ServerStreamingClient[Book]
+
+func (c *libraryServiceClient) WatchBooks(ctx context.Context, in *WatchBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BookEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LibraryService_ServiceDesc.Streams[1], LibraryService_WatchBooks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchBooksRequest, BookEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LibraryService_WatchBooksClient = grpc.ServerStreamingClient[BookEvent]
+
+func (c *libraryServiceClient) BatchCreateBooks(ctx context.Context, in *BatchCreateBooksRequest, opts ...grpc.CallOption) (*BatchCreateBooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCreateBooksResponse)
+	err := c.cc.Invoke(ctx, LibraryService_BatchCreateBooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryServiceClient) BatchGetBooks(ctx context.Context, in *BatchGetBooksRequest, opts ...grpc.CallOption) (*BatchGetBooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetBooksResponse)
+	err := c.cc.Invoke(ctx, LibraryService_BatchGetBooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryServiceClient) BatchDeleteBooks(ctx context.Context, in *BatchDeleteBooksRequest, opts ...grpc.CallOption) (*BatchDeleteBooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchDeleteBooksResponse)
+	err := c.cc.Invoke(ctx, LibraryService_BatchDeleteBooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LibraryServiceServer is the server API for LibraryService service.
 // All implementations must embed UnimplementedLibraryServiceServer
 // for forward compatibility.
 type LibraryServiceServer interface {
 	CreateBook(context.Context, *CreateBookRequest) (*Book, error)
 	GetBook(context.Context, *GetBookRequest) (*Book, error)
+	GetBookByIsbn(context.Context, *GetBookByIsbnRequest) (*GetBookByIsbnResponse, error)
 	UpdateBook(context.Context, *UpdateBookRequest) (*Book, error)
 	DeleteBook(context.Context, *DeleteBookRequest) (*emptypb.Empty, error)
+	UndeleteBook(context.Context, *UndeleteBookRequest) (*Book, error)
+	PurgeBook(context.Context, *PurgeBookRequest) (*emptypb.Empty, error)
+	ListBookRevisions(context.Context, *ListBookRevisionsRequest) (*ListBookRevisionsResponse, error)
+	RestoreBookRevision(context.Context, *RestoreBookRevisionRequest) (*Book, error)
 	ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error)
+	StreamBooks(*StreamBooksRequest, grpc.ServerStreamingServer[Book]) error
+	// Streams the writes made through the serving replica since it started.
+	// The feed is best-effort and single-replica: writes handled by other
+	// replicas are never seen, and events are lost on restart, which makes
+	// earlier cursors fail with OUT_OF_RANGE. Servers only offer it when
+	// started with books.watch for a single-replica deployment, and fail
+	// with UNIMPLEMENTED otherwise.
+	WatchBooks(*WatchBooksRequest, grpc.ServerStreamingServer[BookEvent]) error
+	BatchCreateBooks(context.Context, *BatchCreateBooksRequest) (*BatchCreateBooksResponse, error)
+	BatchGetBooks(context.Context, *BatchGetBooksRequest) (*BatchGetBooksResponse, error)
+	BatchDeleteBooks(context.Context, *BatchDeleteBooksRequest) (*BatchDeleteBooksResponse, error)
 	mustEmbedUnimplementedLibraryServiceServer()
 }
 
@@ -121,15 +281,45 @@ func (UnimplementedLibraryServiceServer) CreateBook(context.Context, *CreateBook
 func (UnimplementedLibraryServiceServer) GetBook(context.Context, *GetBookRequest) (*Book, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
 }
+func (UnimplementedLibraryServiceServer) GetBookByIsbn(context.Context, *GetBookByIsbnRequest) (*GetBookByIsbnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBookByIsbn not implemented")
+}
 func (UnimplementedLibraryServiceServer) UpdateBook(context.Context, *UpdateBookRequest) (*Book, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateBook not implemented")
 }
 func (UnimplementedLibraryServiceServer) DeleteBook(context.Context, *DeleteBookRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteBook not implemented")
 }
+func (UnimplementedLibraryServiceServer) UndeleteBook(context.Context, *UndeleteBookRequest) (*Book, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteBook not implemented")
+}
+func (UnimplementedLibraryServiceServer) PurgeBook(context.Context, *PurgeBookRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeBook not implemented")
+}
+func (UnimplementedLibraryServiceServer) ListBookRevisions(context.Context, *ListBookRevisionsRequest) (*ListBookRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBookRevisions not implemented")
+}
+func (UnimplementedLibraryServiceServer) RestoreBookRevision(context.Context, *RestoreBookRevisionRequest) (*Book, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreBookRevision not implemented")
+}
 func (UnimplementedLibraryServiceServer) ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
 }
+func (UnimplementedLibraryServiceServer) StreamBooks(*StreamBooksRequest, grpc.ServerStreamingServer[Book]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBooks not implemented")
+}
+func (UnimplementedLibraryServiceServer) WatchBooks(*WatchBooksRequest, grpc.ServerStreamingServer[BookEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBooks not implemented")
+}
+func (UnimplementedLibraryServiceServer) BatchCreateBooks(context.Context, *BatchCreateBooksRequest) (*BatchCreateBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateBooks not implemented")
+}
+func (UnimplementedLibraryServiceServer) BatchGetBooks(context.Context, *BatchGetBooksRequest) (*BatchGetBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetBooks not implemented")
+}
+func (UnimplementedLibraryServiceServer) BatchDeleteBooks(context.Context, *BatchDeleteBooksRequest) (*BatchDeleteBooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteBooks not implemented")
+}
 func (UnimplementedLibraryServiceServer) mustEmbedUnimplementedLibraryServiceServer() {}
 func (UnimplementedLibraryServiceServer) testEmbeddedByValue()                        {}
 
@@ -187,6 +377,24 @@ func _LibraryService_GetBook_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LibraryService_GetBookByIsbn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookByIsbnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).GetBookByIsbn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_GetBookByIsbn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).GetBookByIsbn(ctx, req.(*GetBookByIsbnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LibraryService_UpdateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateBookRequest)
 	if err := dec(in); err != nil {
@@ -223,6 +431,78 @@ func _LibraryService_DeleteBook_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LibraryService_UndeleteBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).UndeleteBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_UndeleteBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).UndeleteBook(ctx, req.(*UndeleteBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LibraryService_PurgeBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).PurgeBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_PurgeBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).PurgeBook(ctx, req.(*PurgeBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LibraryService_ListBookRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBookRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).ListBookRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_ListBookRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).ListBookRevisions(ctx, req.(*ListBookRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LibraryService_RestoreBookRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreBookRevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).RestoreBookRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_RestoreBookRevision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).RestoreBookRevision(ctx, req.(*RestoreBookRevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LibraryService_ListBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListBooksRequest)
 	if err := dec(in); err != nil {
@@ -241,6 +521,82 @@ func _LibraryService_ListBooks_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LibraryService_StreamBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LibraryServiceServer).StreamBooks(m, &grpc.GenericServerStream[StreamBooksRequest, Book]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LibraryService_StreamBooksServer = grpc.ServerStreamingServer[Book]
+
+func _LibraryService_WatchBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LibraryServiceServer).WatchBooks(m, &grpc.GenericServerStream[WatchBooksRequest, BookEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LibraryService_WatchBooksServer = grpc.ServerStreamingServer[BookEvent]
+
+func _LibraryService_BatchCreateBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).BatchCreateBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_BatchCreateBooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).BatchCreateBooks(ctx, req.(*BatchCreateBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LibraryService_BatchGetBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).BatchGetBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_BatchGetBooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).BatchGetBooks(ctx, req.(*BatchGetBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LibraryService_BatchDeleteBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteBooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServiceServer).BatchDeleteBooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LibraryService_BatchDeleteBooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServiceServer).BatchDeleteBooks(ctx, req.(*BatchDeleteBooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LibraryService_ServiceDesc is the grpc.ServiceDesc for LibraryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -256,6 +612,10 @@ var LibraryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBook",
 			Handler:    _LibraryService_GetBook_Handler,
 		},
+		{
+			MethodName: "GetBookByIsbn",
+			Handler:    _LibraryService_GetBookByIsbn_Handler,
+		},
 		{
 			MethodName: "UpdateBook",
 			Handler:    _LibraryService_UpdateBook_Handler,
@@ -264,11 +624,50 @@ var LibraryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteBook",
 			Handler:    _LibraryService_DeleteBook_Handler,
 		},
+		{
+			MethodName: "UndeleteBook",
+			Handler:    _LibraryService_UndeleteBook_Handler,
+		},
+		{
+			MethodName: "PurgeBook",
+			Handler:    _LibraryService_PurgeBook_Handler,
+		},
+		{
+			MethodName: "ListBookRevisions",
+			Handler:    _LibraryService_ListBookRevisions_Handler,
+		},
+		{
+			MethodName: "RestoreBookRevision",
+			Handler:    _LibraryService_RestoreBookRevision_Handler,
+		},
 		{
 			MethodName: "ListBooks",
 			Handler:    _LibraryService_ListBooks_Handler,
 		},
+		{
+			MethodName: "BatchCreateBooks",
+			Handler:    _LibraryService_BatchCreateBooks_Handler,
+		},
+		{
+			MethodName: "BatchGetBooks",
+			Handler:    _LibraryService_BatchGetBooks_Handler,
+		},
+		{
+			MethodName: "BatchDeleteBooks",
+			Handler:    _LibraryService_BatchDeleteBooks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBooks",
+			Handler:       _LibraryService_StreamBooks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchBooks",
+			Handler:       _LibraryService_WatchBooks_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/library_service.proto",
 }